@@ -0,0 +1,314 @@
+package tgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/rueidis"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/nekomeowww/tgo/pkg/redis"
+	"github.com/nekomeowww/tgo/pkg/storage/queue"
+	"github.com/nekomeowww/tgo/pkg/storage/ratelimit"
+	"github.com/nekomeowww/tgo/pkg/storage/ttlcache"
+	"github.com/nekomeowww/xo/logger"
+)
+
+type botManagerOptions struct {
+	webhookURL  string
+	webhookPort string
+	listener    net.Listener
+	rueidis     rueidis.Client
+	logger      *logger.Logger
+	botOptions  []CallOption
+}
+
+type BotManagerCallOption func(*botManagerOptions)
+
+// WithBotManagerWebhookURL sets the base webhook URL, each bot receives updates
+// on the base URL followed by its name. Bots poll for updates when it's not set.
+func WithBotManagerWebhookURL(url string) BotManagerCallOption {
+	return func(o *botManagerOptions) {
+		o.webhookURL = url
+	}
+}
+
+// WithBotManagerWebhookPort sets the port of the shared webhook server, defaults to 7071.
+func WithBotManagerWebhookPort(port string) BotManagerCallOption {
+	return func(o *botManagerOptions) {
+		o.webhookPort = port
+	}
+}
+
+// WithBotManagerWebhookListener serves the shared webhook server on the listener.
+func WithBotManagerWebhookListener(listener net.Listener) BotManagerCallOption {
+	return func(o *botManagerOptions) {
+		o.listener = listener
+	}
+}
+
+// WithBotManagerRueidis shares the client among the bots, the keys of each bot
+// are prefixed with its name.
+func WithBotManagerRueidis(rueidis rueidis.Client) BotManagerCallOption {
+	return func(o *botManagerOptions) {
+		o.rueidis = rueidis
+	}
+}
+
+func WithBotManagerLogger(logger *logger.Logger) BotManagerCallOption {
+	return func(o *botManagerOptions) {
+		o.logger = logger
+	}
+}
+
+// WithBotManagerBotOptions sets the options applied to every bot before the
+// options passed to AddBot.
+func WithBotManagerBotOptions(callOpts ...CallOption) BotManagerCallOption {
+	return func(o *botManagerOptions) {
+		o.botOptions = append(o.botOptions, callOpts...)
+	}
+}
+
+// BotManager hosts multiple bots in one process, behind one webhook server.
+type BotManager struct {
+	opts   *botManagerOptions
+	logger *logger.Logger
+
+	mutex           sync.RWMutex
+	bots            map[string]*Bot
+	webhookHandlers map[string]http.Handler
+
+	webhookServer *http.Server
+}
+
+func NewBotManager(callOpts ...BotManagerCallOption) (*BotManager, error) {
+	opts := &botManagerOptions{
+		botOptions: make([]CallOption, 0),
+	}
+
+	for _, callOpt := range callOpts {
+		callOpt(opts)
+	}
+
+	if opts.logger == nil {
+		logger, err := logger.NewLogger(logger.WithLevel(zap.DebugLevel), logger.WithAppName("tgo"), logger.WithNamespace("nekomeowww"))
+		if err != nil {
+			return nil, err
+		}
+
+		opts.logger = logger
+	}
+
+	m := &BotManager{
+		opts:            opts,
+		logger:          opts.logger,
+		bots:            make(map[string]*Bot),
+		webhookHandlers: make(map[string]http.Handler),
+	}
+
+	if opts.webhookURL != "" {
+		m.webhookServer = &http.Server{
+			Addr:              net.JoinHostPort("", lo.Ternary(opts.webhookPort == "", "7071", opts.webhookPort)),
+			ReadTimeout:       time.Second * 15,
+			ReadHeaderTimeout: time.Second * 15,
+			Handler:           m,
+		}
+	}
+
+	return m, nil
+}
+
+// AddBot creates a bot hosted by the manager, name identifies the bot in the
+// webhook path and the key prefix, the bot is not started until StartBot or Start.
+func (m *BotManager) AddBot(name string, callOpts ...CallOption) (*Bot, error) {
+	if name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("invalid bot name %q, must be non-empty and must not contain '/'", name)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.bots[name]; ok {
+		return nil, fmt.Errorf("bot %s already exists", name)
+	}
+
+	opts := []CallOption{WithLogger(m.logger)}
+	opts = append(opts, m.opts.botOptions...)
+
+	if m.opts.rueidis != nil {
+		prefix := redis.BotNamespace1.Format(name)
+
+		opts = append(opts,
+			WithQueue(queue.NewPrefixedQueue(queue.NewRueidisQueue(m.opts.rueidis), prefix)),
+			WithTTLCache(ttlcache.NewPrefixedTTLCache(ttlcache.NewRueidisTTLCache(m.opts.rueidis), prefix)),
+			// keys of the rate limits are already scoped by bot id
+			WithRateLimiter(ratelimit.NewRueidisRateLimiter(m.opts.rueidis)),
+		)
+	}
+	if m.opts.webhookURL != "" {
+		opts = append(opts, WithWebhookURL(strings.TrimSuffix(m.opts.webhookURL, "/")+"/"+name), WithoutWebhookServer())
+	}
+
+	opts = append(opts, callOpts...)
+
+	bot, err := NewBot(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	m.bots[name] = bot
+
+	return bot, nil
+}
+
+// Bot returns the bot added with the name.
+func (m *BotManager) Bot(name string) (*Bot, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	bot, ok := m.bots[name]
+
+	return bot, ok
+}
+
+// Bots returns the names of the bots added.
+func (m *BotManager) Bots() []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return lo.Keys(m.bots)
+}
+
+// StartBot subscribes the updates of the bot and routes its webhook requests.
+func (m *BotManager) StartBot(ctx context.Context, name string) error {
+	bot, ok := m.Bot(name)
+	if !ok {
+		return fmt.Errorf("bot %s not found", name)
+	}
+
+	err := bot.Start(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start bot %s: %w", name, err)
+	}
+
+	if bot.webhookHandler != nil {
+		m.mutex.Lock()
+		m.webhookHandlers[bot.WebhookPath()] = bot.WebhookHandler()
+		m.mutex.Unlock()
+	}
+
+	m.logger.Info("bot started", zap.String("name", name), zap.String("username", bot.Self.UserName))
+
+	return nil
+}
+
+// StopBot stops the bot and removes it from the manager, a stopped bot can't be
+// started again, add it again with AddBot instead.
+func (m *BotManager) StopBot(ctx context.Context, name string) error {
+	m.mutex.Lock()
+
+	bot, ok := m.bots[name]
+	if !ok {
+		m.mutex.Unlock()
+		return fmt.Errorf("bot %s not found", name)
+	}
+
+	delete(m.bots, name)
+
+	if bot.webhookHandler != nil {
+		delete(m.webhookHandlers, bot.WebhookPath())
+	}
+
+	m.mutex.Unlock()
+
+	err := bot.Stop(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to stop bot %s: %w", name, err)
+	}
+
+	m.logger.Info("bot stopped", zap.String("name", name))
+
+	return nil
+}
+
+// ServeHTTP routes the webhook requests to the started bots.
+func (m *BotManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mutex.RLock()
+	handler, ok := m.webhookHandlers[r.URL.Path]
+	m.mutex.RUnlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// WebhookHandler returns the http.Handler that routes the webhook requests to
+// the started bots, to be mounted on your own server.
+func (m *BotManager) WebhookHandler() http.Handler {
+	return m
+}
+
+// Start starts all the bots added, then serves the shared webhook server until
+// it's shut down by Stop.
+func (m *BotManager) Start(ctx context.Context) error {
+	for _, name := range m.Bots() {
+		err := m.StartBot(ctx, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if m.webhookServer == nil {
+		return nil
+	}
+
+	var err error
+
+	l := m.opts.listener
+	if l == nil {
+		l, err = net.Listen("tcp", m.webhookServer.Addr)
+		if err != nil {
+			return err
+		}
+	}
+
+	m.logger.Info("Telegram Bot webhook server is listening", zap.String("addr", l.Addr().String()))
+
+	err = m.webhookServer.Serve(l)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// Stop shuts the shared webhook server down and stops all the bots.
+func (m *BotManager) Stop(ctx context.Context) error {
+	errs := make([]error, 0)
+
+	if m.webhookServer != nil {
+		err := m.webhookServer.Shutdown(ctx)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errs = append(errs, fmt.Errorf("failed to shutdown webhook server: %w", err))
+		}
+	}
+
+	for _, name := range m.Bots() {
+		err := m.StopBot(ctx, name)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,186 @@
+package tgo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nekomeowww/xo/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/nekomeowww/tgo/pkg/redis"
+	"github.com/nekomeowww/tgo/pkg/storage/queue"
+	"github.com/nekomeowww/tgo/pkg/storage/ttlcache"
+)
+
+// newFakeBotAPIServer responds to the Bot API methods of any bot token, getMe
+// responds with the username of the token.
+func newFakeBotAPIServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/bot"), "/")
+		token, method := parts[0], parts[len(parts)-1]
+
+		var result any
+
+		switch method {
+		case "getMe":
+			result = map[string]any{"id": 1, "is_bot": true, "first_name": token, "username": token + "_bot"}
+		case "getWebhookInfo":
+			result = map[string]any{"url": "https://example.com/bots", "pending_update_count": 0}
+		default:
+			result = true
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestBotManager(t *testing.T) {
+	logger, err := logger.NewLogger(logger.WithLevel(zapcore.DebugLevel), logger.WithAppName("tgo"), logger.WithNamespace("nekomeowww"))
+	require.NoError(t, err)
+
+	server := newFakeBotAPIServer(t)
+
+	m, err := NewBotManager(
+		WithBotManagerWebhookURL("https://example.com/bots"),
+		WithBotManagerLogger(logger),
+		WithBotManagerBotOptions(WithAPIEndpoint(server.URL)),
+	)
+	require.NoError(t, err)
+
+	var (
+		mutex      sync.Mutex
+		dispatched []string
+	)
+
+	for _, name := range []string{"a", "b"} {
+		bot, err := m.AddBot(name, WithToken("token-"+name))
+		require.NoError(t, err)
+
+		bot.Use(func(ctx *Context, next func()) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			dispatched = append(dispatched, name)
+		})
+	}
+
+	dispatchedBots := func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return append([]string{}, dispatched...)
+	}
+
+	postUpdate := func(path string) int {
+		body := `{"update_id":1,"message":{"message_id":1,"date":0,"from":{"id":2,"first_name":"Neko"},"chat":{"id":2,"type":"private"},"text":"hi"}}`
+
+		rec := httptest.NewRecorder()
+		m.WebhookHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+
+		return rec.Code
+	}
+
+	t.Run("InvalidName", func(t *testing.T) {
+		_, err := m.AddBot("")
+		require.Error(t, err)
+
+		_, err = m.AddBot("a/b")
+		require.Error(t, err)
+	})
+
+	t.Run("Duplicated", func(t *testing.T) {
+		_, err := m.AddBot("a", WithToken("token-a"))
+		require.Error(t, err)
+	})
+
+	t.Run("NotStarted", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, postUpdate("/bots/a/token-a"))
+	})
+
+	t.Run("Routing", func(t *testing.T) {
+		require.NoError(t, m.StartBot(context.Background(), "a"))
+		require.NoError(t, m.StartBot(context.Background(), "b"))
+
+		bot, ok := m.Bot("a")
+		require.True(t, ok)
+		assert.Equal(t, "/bots/a/token-a", bot.WebhookPath())
+		assert.Equal(t, "token-a_bot", bot.Self.UserName)
+
+		assert.Equal(t, http.StatusOK, postUpdate("/bots/b/token-b"))
+		require.Eventually(t, func() bool { return len(dispatchedBots()) == 1 }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{"b"}, dispatchedBots())
+
+		assert.Equal(t, http.StatusNotFound, postUpdate("/bots/a/token-b"))
+		assert.Equal(t, http.StatusNotFound, postUpdate("/bots/c/token-c"))
+	})
+
+	t.Run("StopBot", func(t *testing.T) {
+		require.NoError(t, m.StopBot(context.Background(), "b"))
+		require.Error(t, m.StopBot(context.Background(), "b"))
+
+		assert.ElementsMatch(t, []string{"a"}, m.Bots())
+		assert.Equal(t, http.StatusNotFound, postUpdate("/bots/b/token-b"))
+		assert.Equal(t, http.StatusOK, postUpdate("/bots/a/token-a"))
+		require.Eventually(t, func() bool { return len(dispatchedBots()) == 2 }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{"b", "a"}, dispatchedBots())
+
+		require.Error(t, m.StartBot(context.Background(), "b"))
+		require.NoError(t, m.Stop(context.Background()))
+		assert.Empty(t, m.Bots())
+	})
+}
+
+func TestBotManagerKeyPrefix(t *testing.T) {
+	sharedQueue := queue.NewInMemoryQueue()
+	sharedCache := ttlcache.NewInMemoryTTLCache()
+
+	queueOf := func(name string) queue.Queue {
+		return queue.NewPrefixedQueue(sharedQueue, redis.BotNamespace1.Format(name))
+	}
+	cacheOf := func(name string) ttlcache.TTLCache {
+		return ttlcache.NewPrefixedTTLCache(sharedCache, redis.BotNamespace1.Format(name))
+	}
+
+	ctx := context.Background()
+
+	// the same key of two bots doesn't collide
+	set, err := cacheOf("a").SetNX(ctx, "key", "a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, set)
+
+	set, err = cacheOf("b").SetNX(ctx, "key", "b", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, set)
+
+	value, err := sharedCache.Get(ctx, "bot/a/key")
+	require.NoError(t, err)
+	assert.Equal(t, "a", value.OrEmpty())
+
+	value, err = cacheOf("b").Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "b", value.OrEmpty())
+
+	require.NoError(t, queueOf("a").Push(ctx, "group", "a"))
+	require.NoError(t, queueOf("b").Push(ctx, "group", "b"))
+
+	values, err := queueOf("a").PopAll(ctx, "group")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, values)
+
+	values, err = sharedQueue.PopAll(ctx, "bot/b/group")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, values)
+}
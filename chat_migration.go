@@ -0,0 +1,185 @@
+package tgo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"github.com/nekomeowww/tgo/pkg/redis"
+)
+
+// chatMigrationTTL is how long the migrations are remembered, a group only
+// migrates once, it's kept long enough for the pending sends to the group.
+const chatMigrationTTL = 30 * 24 * time.Hour
+
+// chatMigrationHooksRetryInterval is how long the hooks of a migration are left to
+// the caller elected, the hooks that failed are called again by the migrations
+// recorded after it.
+var chatMigrationHooksRetryInterval = time.Minute
+
+// ChatMigrationHook is called once when a group migrated to a supergroup, either
+// noticed by the service messages of the migration or a failed send to the group,
+// migrate the per-chat storage keys of the application from fromChatID to toChatID.
+// The hooks are all called again when any of them failed, keep them idempotent.
+type ChatMigrationHook func(bot *BotAPI, fromChatID int64, toChatID int64) error
+
+// OnChatMigration registers the hook that is called when a group migrated to a supergroup.
+func (d *Dispatcher) OnChatMigration(hook ChatMigrationHook) {
+	d.chatMigrationHooks = append(d.chatMigrationHooks, hook)
+}
+
+// MigrateChat records that the group fromChatID migrated to the supergroup
+// toChatID, and calls the hooks registered by OnChatMigration until they succeed
+// once, the hooks that failed are called again when the migration is recorded
+// again, e.g. by a send to the group.
+func (b *BotAPI) MigrateChat(fromChatID int64, toChatID int64) error {
+	if fromChatID == 0 || toChatID == 0 || fromChatID == toChatID {
+		return nil
+	}
+
+	err := b.ttlcache.Set(context.Background(), redis.ChatMigration2.Format(b.Self.ID, fromChatID), strconv.FormatInt(toChatID, 10), chatMigrationTTL)
+	if err != nil {
+		return err
+	}
+
+	hookedKey := redis.ChatMigrationHooked2.Format(b.Self.ID, fromChatID)
+
+	hooked, err := b.ttlcache.Get(context.Background(), hookedKey)
+	if err != nil {
+		return err
+	}
+	if hooked.IsPresent() {
+		return nil
+	}
+
+	hooking, err := b.ttlcache.SetNX(context.Background(), redis.ChatMigrationHooking2.Format(b.Self.ID, fromChatID), "1", chatMigrationHooksRetryInterval)
+	if err != nil {
+		return err
+	}
+	if !hooking {
+		return nil
+	}
+
+	b.logger.Info("chat migrated", zap.Int64("from_chat_id", fromChatID), zap.Int64("to_chat_id", toChatID))
+
+	errs := make([]error, 0)
+
+	for _, hook := range b.chatMigrationHooks {
+		errs = append(errs, hook(b, fromChatID, toChatID))
+	}
+
+	err = errors.Join(errs...)
+	if err != nil {
+		return err
+	}
+
+	return b.ttlcache.Set(context.Background(), hookedKey, "1", chatMigrationTTL)
+}
+
+// MigratedChatID returns the id of the supergroup that the group migrated to, and
+// whether the migration is recorded.
+func (b *BotAPI) MigratedChatID(chatID int64) (int64, bool, error) {
+	value, err := b.ttlcache.Get(context.Background(), redis.ChatMigration2.Format(b.Self.ID, chatID))
+	if err != nil {
+		return 0, false, err
+	}
+
+	str, ok := value.Get()
+	if !ok {
+		return 0, false, nil
+	}
+
+	toChatID, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return toChatID, true, nil
+}
+
+// withChatMigration calls fn with the chattable, when it fails because the group
+// migrated, records the migration and calls fn again with the chattable sent to
+// the supergroup instead.
+func withChatMigration[T any](b *BotAPI, chattable tgbotapi.Chattable, fn func(tgbotapi.Chattable) (T, error)) (T, error) {
+	result, err := fn(chattable)
+
+	var botAPIErr *BotAPIError
+	if !errors.As(err, &botAPIErr) || botAPIErr.MigrateToChatID == 0 {
+		return result, err
+	}
+
+	fromChatID, _ := chatIDOfChattable(chattable)
+
+	migrated, ok := chattableWithChatID(chattable, botAPIErr.MigrateToChatID)
+	if !ok {
+		return result, err
+	}
+
+	migrateErr := b.MigrateChat(fromChatID, botAPIErr.MigrateToChatID)
+	if migrateErr != nil {
+		b.logger.Error("failed to migrate chat",
+			zap.Int64("from_chat_id", fromChatID),
+			zap.Int64("to_chat_id", botAPIErr.MigrateToChatID),
+			zap.Error(migrateErr),
+		)
+	}
+
+	return fn(migrated)
+}
+
+// chatIDOfChattable returns the ChatID field of the chattable.
+func chatIDOfChattable(chattable tgbotapi.Chattable) (int64, bool) {
+	v := reflect.Indirect(reflect.ValueOf(chattable))
+	if v.Kind() != reflect.Struct {
+		return 0, false
+	}
+
+	chatID := v.FieldByName("ChatID")
+	if !chatID.IsValid() || chatID.Kind() != reflect.Int64 || chatID.Int() == 0 {
+		return 0, false
+	}
+
+	return chatID.Int(), true
+}
+
+// chattableWithChatID returns a copy of the chattable with the ChatID field set to
+// chatID, the chattable itself is left untouched.
+func chattableWithChatID(chattable tgbotapi.Chattable, chatID int64) (tgbotapi.Chattable, bool) {
+	v := reflect.ValueOf(chattable)
+	isPtr := v.Kind() == reflect.Ptr
+
+	if isPtr {
+		if v.IsNil() {
+			return nil, false
+		}
+
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	copied := reflect.New(v.Type())
+	copied.Elem().Set(v)
+
+	field := copied.Elem().FieldByName("ChatID")
+	if !field.IsValid() || field.Kind() != reflect.Int64 || !field.CanSet() || field.Int() == 0 {
+		return nil, false
+	}
+
+	field.SetInt(chatID)
+
+	if isPtr {
+		migrated, ok := copied.Interface().(tgbotapi.Chattable)
+		return migrated, ok
+	}
+
+	migrated, ok := copied.Elem().Interface().(tgbotapi.Chattable)
+
+	return migrated, ok
+}
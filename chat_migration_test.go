@@ -0,0 +1,165 @@
+package tgo
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nekomeowww/tgo/pkg/storage/queue"
+	"github.com/nekomeowww/tgo/pkg/storage/ttlcache"
+	"github.com/nekomeowww/xo/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestMigrateChat(t *testing.T) {
+	logger, err := logger.NewLogger(logger.WithLevel(zapcore.DebugLevel), logger.WithAppName("tgo"), logger.WithNamespace("nekomeowww"))
+	require.NoError(t, err)
+
+	migrations := make([][2]int64, 0)
+
+	bot := &BotAPI{
+		BotAPI:   &tgbotapi.BotAPI{Self: tgbotapi.User{ID: 1}},
+		logger:   logger,
+		queue:    queue.NewInMemoryQueue(),
+		ttlcache: ttlcache.NewInMemoryTTLCache(),
+		chatMigrationHooks: []ChatMigrationHook{
+			func(bot *BotAPI, fromChatID int64, toChatID int64) error {
+				migrations = append(migrations, [2]int64{fromChatID, toChatID})
+				return nil
+			},
+		},
+	}
+
+	_, ok, err := bot.MigratedChatID(-123)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, bot.MigrateChat(-123, -100123))
+	require.NoError(t, bot.MigrateChat(-123, -100123))
+	assert.Equal(t, [][2]int64{{-123, -100123}}, migrations)
+
+	toChatID, ok, err := bot.MigratedChatID(-123)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(-100123), toChatID)
+}
+
+func TestMigrateChatHookFailed(t *testing.T) {
+	logger, err := logger.NewLogger(logger.WithLevel(zapcore.DebugLevel), logger.WithAppName("tgo"), logger.WithNamespace("nekomeowww"))
+	require.NoError(t, err)
+
+	retryInterval := chatMigrationHooksRetryInterval
+	chatMigrationHooksRetryInterval = 10 * time.Millisecond
+
+	defer func() {
+		chatMigrationHooksRetryInterval = retryInterval
+	}()
+
+	calls := 0
+
+	bot := &BotAPI{
+		BotAPI:   &tgbotapi.BotAPI{Self: tgbotapi.User{ID: 1}},
+		logger:   logger,
+		queue:    queue.NewInMemoryQueue(),
+		ttlcache: ttlcache.NewInMemoryTTLCache(),
+		chatMigrationHooks: []ChatMigrationHook{
+			func(bot *BotAPI, fromChatID int64, toChatID int64) error {
+				calls++
+				if calls == 1 {
+					return errors.New("storage unavailable")
+				}
+
+				return nil
+			},
+		},
+	}
+
+	require.Error(t, bot.MigrateChat(-123, -100123))
+
+	// the migration is recorded even though the hook failed
+	toChatID, ok, err := bot.MigratedChatID(-123)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(-100123), toChatID)
+
+	// still elected to the previous caller
+	require.NoError(t, bot.MigrateChat(-123, -100123))
+	assert.Equal(t, 1, calls)
+
+	time.Sleep(20 * time.Millisecond)
+
+	require.NoError(t, bot.MigrateChat(-123, -100123))
+	require.NoError(t, bot.MigrateChat(-123, -100123))
+	assert.Equal(t, 2, calls)
+}
+
+func TestWithChatMigration(t *testing.T) {
+	logger, err := logger.NewLogger(logger.WithLevel(zapcore.DebugLevel), logger.WithAppName("tgo"), logger.WithNamespace("nekomeowww"))
+	require.NoError(t, err)
+
+	bot := &BotAPI{
+		BotAPI:   &tgbotapi.BotAPI{Self: tgbotapi.User{ID: 1}},
+		logger:   logger,
+		queue:    queue.NewInMemoryQueue(),
+		ttlcache: ttlcache.NewInMemoryTTLCache(),
+	}
+
+	chatIDs := make([]int64, 0)
+
+	message, err := withChatMigration(bot, tgbotapi.NewMessage(-123, "hello"), func(chattable tgbotapi.Chattable) (tgbotapi.Message, error) {
+		chatID, _ := chatIDOfChattable(chattable)
+		chatIDs = append(chatIDs, chatID)
+
+		if chatID == -123 {
+			return tgbotapi.Message{}, NewBotAPIError(&tgbotapi.Error{
+				Code:               400,
+				Message:            "Bad Request: group chat was upgraded to a supergroup chat",
+				ResponseParameters: tgbotapi.ResponseParameters{MigrateToChatID: -100123},
+			})
+		}
+
+		return tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: chatID}}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(-100123), message.Chat.ID)
+	assert.Equal(t, []int64{-123, -100123}, chatIDs)
+
+	toChatID, ok, err := bot.MigratedChatID(-123)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(-100123), toChatID)
+
+	t.Run("OtherErrors", func(t *testing.T) {
+		attempts := 0
+
+		_, err := withChatMigration(bot, tgbotapi.NewMessage(-456, "hello"), func(chattable tgbotapi.Chattable) (tgbotapi.Message, error) {
+			attempts++
+			return tgbotapi.Message{}, errors.New("network")
+		})
+		require.Error(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+}
+
+func TestChattableWithChatID(t *testing.T) {
+	message := tgbotapi.NewMessage(-123, "hello")
+
+	migrated, ok := chattableWithChatID(message, -100123)
+	require.True(t, ok)
+	assert.Equal(t, int64(-100123), migrated.(tgbotapi.MessageConfig).ChatID)
+	assert.Equal(t, "hello", migrated.(tgbotapi.MessageConfig).Text)
+	assert.Equal(t, int64(-123), message.ChatID)
+
+	edit := tgbotapi.NewEditMessageText(-123, 1, "hello")
+
+	migrated, ok = chattableWithChatID(&edit, -100123)
+	require.True(t, ok)
+	assert.Equal(t, int64(-100123), migrated.(*tgbotapi.EditMessageTextConfig).ChatID)
+	assert.Equal(t, int64(-123), edit.ChatID)
+
+	_, ok = chattableWithChatID(tgbotapi.NewMessageToChannel("@channel", "hello"), -100123)
+	assert.False(t, ok)
+}
@@ -1,5 +1,8 @@
 package tgo
 
 const (
+	// MessageLengthLimit is the maximum length of the text of a message, measured by MessageTextLength.
 	MessageLengthLimit = 4096
+	// CaptionLengthLimit is the maximum length of the caption of a media, measured by MessageTextLength.
+	CaptionLengthLimit = 1024
 )
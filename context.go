@@ -1,6 +1,7 @@
 package tgo
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"sync"
@@ -48,18 +49,65 @@ type Context struct {
 
 	isCallbackQuery         bool
 	callBackQueryActionData string
+
+	mediaGroup []tgbotapi.Message
+
+	lastSentMessages []tgbotapi.Message
+
+	webhookReply *webhookReply
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	// holds counts the dispatching of the update and the handlers running with
+	// the context, the context is cancelled once all of them returned
+	holds int
 }
 
 func NewContext(bot *tgbotapi.BotAPI, botAPI *BotAPI, update tgbotapi.Update, logger *logger.Logger, i18n *i18n.I18n) *Context {
+	parent := context.Background()
+	if botAPI != nil {
+		parent = botAPI.context()
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+
 	return &Context{
 		Bot:             botAPI,
 		Update:          update,
 		Logger:          logger,
 		I18n:            i18n,
 		isCallbackQuery: false,
+		ctx:             ctx,
+		cancel:          cancel,
 	}
 }
 
+func (c *Context) hold() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.holds++
+}
+
+// release cancels the context once the dispatching and all the handlers returned,
+// so that it doesn't stay registered as a child of the context of the bot.
+func (c *Context) release() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.holds--
+	if c.holds <= 0 {
+		c.cancel()
+	}
+}
+
+// Context returns the context.Context of the update, which is cancelled once the
+// handlers of the update returned, when the shutdown of the dispatcher cuts off
+// the handler, or the bot stopped.
+func (c *Context) Context() context.Context {
+	return c.ctx
+}
+
 func (c *Context) UpdateType() UpdateType {
 	switch {
 	case c.Update.Message != nil:
@@ -160,6 +208,23 @@ func (c *Context) BindFromCallbackQueryData(dst any) error {
 	return json.Unmarshal([]byte(c.callBackQueryActionData), dst)
 }
 
+// LastSentMessages returns the messages sent by the last response processed with
+// the context, all the sent messages of the batch for Responses. The responses
+// carried by the webhook response send no messages that can be known.
+func (c *Context) LastSentMessages() []tgbotapi.Message {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.lastSentMessages
+}
+
+func (c *Context) withLastSentMessages(messages []tgbotapi.Message) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.lastSentMessages = messages
+}
+
 func (c *Context) IsBotAdministrator() (bool, error) {
 	return c.Bot.IsBotAdministrator(c.Update.FromChat().ID)
 }
@@ -191,3 +256,51 @@ func (c *Context) NewEditMessageTextAndReplyMarkup(messageID int, text string, r
 func (c *Context) NewEditMessageReplyMarkup(messageID int, replyMarkup tgbotapi.InlineKeyboardMarkup) EditMessageResponse {
 	return NewEditMessageReplyMarkup(c.Update.FromChat().ID, messageID, replyMarkup)
 }
+
+func (c *Context) NewPhoto(file tgbotapi.RequestFileData) MediaResponse {
+	return NewPhoto(c.Update.FromChat().ID, file)
+}
+
+func (c *Context) NewDocument(file tgbotapi.RequestFileData) MediaResponse {
+	return NewDocument(c.Update.FromChat().ID, file)
+}
+
+func (c *Context) NewVideo(file tgbotapi.RequestFileData) MediaResponse {
+	return NewVideo(c.Update.FromChat().ID, file)
+}
+
+func (c *Context) NewAnimation(file tgbotapi.RequestFileData) MediaResponse {
+	return NewAnimation(c.Update.FromChat().ID, file)
+}
+
+func (c *Context) NewAudio(file tgbotapi.RequestFileData) MediaResponse {
+	return NewAudio(c.Update.FromChat().ID, file)
+}
+
+func (c *Context) NewVoice(file tgbotapi.RequestFileData) MediaResponse {
+	return NewVoice(c.Update.FromChat().ID, file)
+}
+
+func (c *Context) NewSticker(file tgbotapi.RequestFileData) MediaResponse {
+	return NewSticker(c.Update.FromChat().ID, file)
+}
+
+func (c *Context) NewLocation(latitude float64, longitude float64) MediaResponse {
+	return NewLocation(c.Update.FromChat().ID, latitude, longitude)
+}
+
+func (c *Context) NewVenue(title string, address string, latitude float64, longitude float64) MediaResponse {
+	return NewVenue(c.Update.FromChat().ID, title, address, latitude, longitude)
+}
+
+func (c *Context) NewContact(phoneNumber string, firstName string) MediaResponse {
+	return NewContact(c.Update.FromChat().ID, phoneNumber, firstName)
+}
+
+func (c *Context) NewDice(emoji string) MediaResponse {
+	return NewDice(c.Update.FromChat().ID, emoji)
+}
+
+func (c *Context) NewMediaGroup() MediaGroupResponse {
+	return NewMediaGroup(c.Update.FromChat().ID)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"runtime/debug"
 	"strings"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/gookit/color"
@@ -15,6 +16,35 @@ import (
 	"golang.org/x/text/language"
 )
 
+var messageUpdateTypes = []UpdateType{
+	UpdateTypeLeftChatMember,
+	UpdateTypeNewChatMembers,
+	UpdateTypeChatMigrationFrom,
+	UpdateTypeChatMigrationTo,
+}
+
+// telegramDefaultUpdateTypes are the update types that Telegram sends when
+// allowed_updates is not specified.
+var telegramDefaultUpdateTypes = []UpdateType{
+	UpdateTypeMessage,
+	UpdateTypeEditedMessage,
+	UpdateTypeChannelPost,
+	UpdateTypeEditedChannelPost,
+	UpdateTypeInlineQuery,
+	UpdateTypeChosenInlineResult,
+	UpdateTypeCallbackQuery,
+	UpdateTypeShippingQuery,
+	UpdateTypePreCheckoutQuery,
+	UpdateTypePoll,
+	UpdateTypePollAnswer,
+	UpdateTypeMyChatMember,
+	UpdateTypeChatJoinRequest,
+}
+
+// nopCallbackQueryRoute is the route of the callback queries that do nothing,
+// registered by NewDispatcher.
+const nopCallbackQueryRoute = "nop"
+
 type Dispatcher struct {
 	logger *logger.Logger
 
@@ -29,7 +59,14 @@ type Dispatcher struct {
 	leftChatMemberHandlers     []Handler
 	newChatMembersHandlers     []Handler
 	myChatMemberHandlers       []Handler
+	chatMemberHandlers         []Handler
 	chatMigrationFromHandlers  []Handler
+	chatMigrationHooks         []ChatMigrationHook
+	mediaGroupHandlers         []Handler
+	mediaGroupWindow           time.Duration
+	allowedUpdates             []UpdateType
+
+	inFlight *inFlightHandlers
 }
 
 func NewDispatcher(logger *logger.Logger) *Dispatcher {
@@ -46,7 +83,13 @@ func NewDispatcher(logger *logger.Logger) *Dispatcher {
 		leftChatMemberHandlers:     make([]Handler, 0),
 		newChatMembersHandlers:     make([]Handler, 0),
 		myChatMemberHandlers:       make([]Handler, 0),
+		chatMemberHandlers:         make([]Handler, 0),
 		chatMigrationFromHandlers:  make([]Handler, 0),
+		chatMigrationHooks:         make([]ChatMigrationHook, 0),
+		mediaGroupHandlers:         make([]Handler, 0),
+		mediaGroupWindow:           DefaultMediaGroupWindow,
+		allowedUpdates:             make([]UpdateType, 0),
+		inFlight:                   newInFlightHandlers(),
 	}
 
 	d.startCommandHandler.helpCommandHandler = d.helpCommand
@@ -58,7 +101,7 @@ func NewDispatcher(logger *logger.Logger) *Dispatcher {
 		{Command: d.cancelCommand.Command(), HelpMessage: d.cancelCommand.CommandHelp, Handler: NewHandler(d.cancelCommand.handle)},
 		{Command: d.startCommandHandler.Command(), HelpMessage: d.startCommandHandler.CommandHelp, Handler: NewHandler(d.startCommandHandler.handle)},
 	})
-	d.OnCallbackQuery("nop", NewHandler(func(ctx *Context) (Response, error) {
+	d.OnCallbackQuery(nopCallbackQueryRoute, NewHandler(func(ctx *Context) (Response, error) {
 		return nil, nil
 	}))
 
@@ -69,6 +112,64 @@ func (d *Dispatcher) Use(middleware MiddlewareFunc) {
 	d.middlewares = append(d.middlewares, middleware)
 }
 
+// AllowUpdates explicitly adds update types to the allowed_updates that the bot
+// asks Telegram for, in addition to the ones derived from the registered handlers.
+// Useful for the update types that are only consumed by middlewares, as the
+// middlewares receive every update type that Telegram sends by default until
+// AllowUpdates narrows them down.
+func (d *Dispatcher) AllowUpdates(updateTypes ...UpdateType) {
+	d.allowedUpdates = append(d.allowedUpdates, updateTypes...)
+}
+
+// AllowedUpdates returns the allowed_updates derived from the registered handlers
+// and the update types added by AllowUpdates. When middlewares are registered and
+// AllowUpdates is never called, the update types that Telegram sends by default
+// are included as well, since the update types that the middlewares consume can't
+// be derived.
+func (d *Dispatcher) AllowedUpdates() []string {
+	// commands are always registered
+	updateTypes := []UpdateType{UpdateTypeMessage}
+
+	if len(d.middlewares) > 0 && len(d.allowedUpdates) == 0 {
+		updateTypes = append(updateTypes, telegramDefaultUpdateTypes...)
+	}
+	if d.hasCallbackQueryRoutes() {
+		updateTypes = append(updateTypes, UpdateTypeCallbackQuery)
+	}
+	// the albums posted to channels are delivered to the media group handlers too
+	if len(d.channelPostHandlers) > 0 || len(d.mediaGroupHandlers) > 0 {
+		updateTypes = append(updateTypes, UpdateTypeChannelPost)
+	}
+	if len(d.myChatMemberHandlers) > 0 {
+		updateTypes = append(updateTypes, UpdateTypeMyChatMember)
+	}
+	if len(d.chatMemberHandlers) > 0 {
+		updateTypes = append(updateTypes, UpdateTypeChatMember)
+	}
+
+	updateTypes = append(updateTypes, d.allowedUpdates...)
+
+	return lo.Uniq(lo.FilterMap(updateTypes, func(updateType UpdateType, _ int) (string, bool) {
+		if updateType == UpdateTypeUnknown {
+			return "", false
+		}
+		// not real update types, they are delivered as messages
+		if lo.Contains(messageUpdateTypes, updateType) {
+			return string(UpdateTypeMessage), true
+		}
+
+		return string(updateType), true
+	}))
+}
+
+// hasCallbackQueryRoutes reports whether any route other than the nop one
+// registered by NewDispatcher is registered.
+func (d *Dispatcher) hasCallbackQueryRoutes() bool {
+	return lo.SomeBy(lo.Values(d.callbackQueryHandlersRoute), func(route string) bool {
+		return route != nopCallbackQueryRoute
+	})
+}
+
 func (d *Dispatcher) OnCommand(cmd string, commandHelp func(c *Context) string, h Handler) {
 	d.helpCommand.defaultGroup.commands = append(d.helpCommand.defaultGroup.commands, Command{
 		Command:     cmd,
@@ -121,8 +222,11 @@ func (d *Dispatcher) dispatchMessage(c *Context) {
 			lo.Ternary(c.Update.Message.Text == "", "<empty or contains medias>", c.Update.Message.Text)),
 		)
 	}
+	if c.Update.Message.MediaGroupID != "" {
+		d.dispatchMediaGroup(c, c.Update.Message)
+	}
 	if c.Update.Message.Command() != "" {
-		d.dispatchInGoroutine(func() {
+		d.dispatchInGoroutine(c, "command:/"+c.Update.Message.Command(), func() {
 			for cmd, f := range d.commandHandlers {
 				if c.Update.Message.Command() == cmd {
 					_, _ = f(c)
@@ -144,7 +248,11 @@ func (d *Dispatcher) dispatchChannelPost(c *Context) {
 		lo.Ternary(c.Update.ChannelPost.Text == "", "<empty or contains medias>", c.Update.ChannelPost.Text),
 	))
 
-	d.dispatchInGoroutine(func() {
+	if c.Update.ChannelPost.MediaGroupID != "" {
+		d.dispatchMediaGroup(c, c.Update.ChannelPost)
+	}
+
+	d.dispatchInGoroutine(c, "channel_post", func() {
 		for _, h := range d.channelPostHandlers {
 			_, _ = h.Handle(c)
 		}
@@ -247,7 +355,7 @@ func (d *Dispatcher) dispatchCallbackQuery(c *Context) {
 
 	c.withCallbackQueryActionData(actionData)
 
-	d.dispatchInGoroutine(func() {
+	d.dispatchInGoroutine(c, "callback_query:"+route, func() {
 		_, _ = handler(c)
 	})
 }
@@ -313,13 +421,44 @@ func (d *Dispatcher) dispatchMyChatMember(c *Context) {
 		d.logger.Debug(fmt.Sprintf("已加入频道 %s (%d)", c.Update.MyChatMember.Chat.Title, c.Update.MyChatMember.Chat.ID))
 	}
 
-	d.dispatchInGoroutine(func() {
+	d.dispatchInGoroutine(c, "my_chat_member", func() {
 		for _, h := range d.myChatMemberHandlers {
 			_, _ = h.Handle(c)
 		}
 	})
 }
 
+func (d *Dispatcher) OnChatMember(handler Handler) {
+	d.chatMemberHandlers = append(d.chatMemberHandlers, handler)
+}
+
+func (d *Dispatcher) dispatchChatMember(c *Context) {
+	member := lo.FromPtr(c.Update.ChatMember.NewChatMember.User)
+
+	identityStrings := make([]string, 0)
+	identityStrings = append(identityStrings, FullNameFromFirstAndLastName(member.FirstName, member.LastName))
+
+	if member.UserName != "" {
+		identityStrings = append(identityStrings, "@"+member.UserName)
+	}
+
+	d.logger.Debug(fmt.Sprintf("[成员信息更新｜%s] [%s (%s)] %s (%s): 成员状态自 %s 变更为 %s",
+		MapChatTypeToChineseText(ChatType(c.Update.ChatMember.Chat.Type)),
+		color.FgGreen.Render(c.Update.ChatMember.Chat.Title),
+		color.FgYellow.Render(c.Update.ChatMember.Chat.ID),
+		strings.Join(identityStrings, " "),
+		color.FgYellow.Render(member.ID),
+		MapMemberStatusToChineseText(MemberStatus(c.Update.ChatMember.OldChatMember.Status)),
+		MapMemberStatusToChineseText(MemberStatus(c.Update.ChatMember.NewChatMember.Status)),
+	))
+
+	d.dispatchInGoroutine(c, "chat_member", func() {
+		for _, h := range d.chatMemberHandlers {
+			_, _ = h.Handle(c)
+		}
+	})
+}
+
 func (d *Dispatcher) OnLeftChatMember(h Handler) {
 	d.leftChatMemberHandlers = append(d.leftChatMemberHandlers, h)
 }
@@ -340,7 +479,7 @@ func (d *Dispatcher) dispatchLeftChatMember(c *Context) {
 		color.FgYellow.Render(c.Update.Message.LeftChatMember.ID),
 	))
 
-	d.dispatchInGoroutine(func() {
+	d.dispatchInGoroutine(c, "left_chat_member", func() {
 		for _, h := range d.leftChatMemberHandlers {
 			_, _ = h.Handle(c)
 		}
@@ -348,7 +487,7 @@ func (d *Dispatcher) dispatchLeftChatMember(c *Context) {
 }
 
 func (d *Dispatcher) OnNewChatMember(h Handler) {
-	d.leftChatMemberHandlers = append(d.leftChatMemberHandlers, h)
+	d.newChatMembersHandlers = append(d.newChatMembersHandlers, h)
 }
 
 func (d *Dispatcher) dispatchNewChatMember(c *Context) {
@@ -373,7 +512,7 @@ func (d *Dispatcher) dispatchNewChatMember(c *Context) {
 		strings.Join(identities, ", "),
 	))
 
-	d.dispatchInGoroutine(func() {
+	d.dispatchInGoroutine(c, "new_chat_members", func() {
 		for _, h := range d.newChatMembersHandlers {
 			_, _ = h.Handle(c)
 		}
@@ -392,7 +531,9 @@ func (d *Dispatcher) dispatchChatMigrationFrom(c *Context) {
 		color.FgYellow.Render(c.Update.Message.MigrateFromChatID),
 	))
 
-	d.dispatchInGoroutine(func() {
+	d.dispatchInGoroutine(c, "chat_migration_from", func() {
+		d.migrateChat(c, c.Update.Message.MigrateFromChatID, c.Update.Message.Chat.ID)
+
 		for _, h := range d.chatMigrationFromHandlers {
 			_, _ = h.Handle(c)
 		}
@@ -406,14 +547,47 @@ func (d *Dispatcher) dispatchChatMigrationTo(c *Context) {
 		color.FgGreen.Render(c.Update.Message.Chat.Title),
 		color.FgYellow.Render(c.Update.Message.MigrateToChatID),
 	))
+
+	d.dispatchInGoroutine(c, "chat_migration_to", func() {
+		d.migrateChat(c, c.Update.Message.Chat.ID, c.Update.Message.MigrateToChatID)
+	})
+}
+
+// migrateChat records the migration, both the group and the supergroup receive a
+// service message of it, the hooks are only called for the first one.
+func (d *Dispatcher) migrateChat(c *Context, fromChatID int64, toChatID int64) {
+	err := c.Bot.MigrateChat(fromChatID, toChatID)
+	if err != nil {
+		d.logger.Error("failed to migrate chat",
+			zap.Int64("from_chat_id", fromChatID),
+			zap.Int64("to_chat_id", toChatID),
+			zap.Error(err),
+		)
+	}
 }
 
 func (d *Dispatcher) Dispatch(bot *tgbotapi.BotAPI, botAPI *BotAPI, i18n *i18n.I18n, update tgbotapi.Update) {
+	d.dispatch(bot, botAPI, i18n, update, nil)
+}
+
+func (d *Dispatcher) dispatch(bot *tgbotapi.BotAPI, botAPI *BotAPI, i18n *i18n.I18n, update tgbotapi.Update, reply *webhookReply) {
+	if d.inFlight.isDraining() {
+		d.logger.Debug("dispatcher is shutting down, dropped update", zap.Int("update_id", update.UpdateID))
+		return
+	}
+
 	for _, m := range d.middlewares {
-		m(NewContext(bot, botAPI, update, d.logger, i18n), func() {})
+		middlewareCtx := NewContext(bot, botAPI, update, d.logger, i18n)
+		m(middlewareCtx, func() {})
+		middlewareCtx.cancel()
 	}
 
 	ctx := NewContext(bot, botAPI, update, d.logger, i18n)
+	ctx.webhookReply = reply
+
+	ctx.hold()
+	defer ctx.release()
+
 	switch ctx.UpdateType() {
 	case UpdateTypeMessage:
 		d.dispatchMessage(ctx)
@@ -440,7 +614,7 @@ func (d *Dispatcher) Dispatch(bot *tgbotapi.BotAPI, botAPI *BotAPI, i18n *i18n.I
 	case UpdateTypeMyChatMember:
 		d.dispatchMyChatMember(ctx)
 	case UpdateTypeChatMember:
-		d.logger.Debug("chat member is not supported yet")
+		d.dispatchChatMember(ctx)
 	case UpdateTypeLeftChatMember:
 		d.dispatchLeftChatMember(ctx)
 	case UpdateTypeNewChatMembers:
@@ -458,8 +632,26 @@ func (d *Dispatcher) Dispatch(bot *tgbotapi.BotAPI, botAPI *BotAPI, i18n *i18n.I
 	}
 }
 
-func (d *Dispatcher) dispatchInGoroutine(f func()) {
+func (d *Dispatcher) dispatchInGoroutine(c *Context, name string, f func()) {
+	c.hold()
+
+	id, ok := d.inFlight.add(c, name)
+	if !ok {
+		d.logger.Debug("dispatcher is shutting down, dropped handler",
+			zap.String("handler", name),
+			zap.Int("update_id", c.Update.UpdateID),
+		)
+		c.release()
+
+		return
+	}
+
+	c.webhookReply.hold()
+
 	go func() {
+		defer d.inFlight.done(id)
+		defer c.release()
+		defer c.webhookReply.release()
 		defer func() {
 			if err := recover(); err != nil {
 				d.logger.Error("Panic recovered from command dispatcher",
@@ -0,0 +1,205 @@
+package tgo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/nekomeowww/tgo/pkg/redis"
+)
+
+// DefaultMediaGroupWindow is how long the media group is buffered after its last
+// message before the group is delivered, Telegram sends the messages of an album as
+// separate updates in a row.
+const DefaultMediaGroupWindow = time.Second
+
+// mediaGroupCollectMargin delays the collecting until the election of the
+// collector expired, so that the messages buffered afterwards elect a new one
+// instead of being left in the buffer.
+const mediaGroupCollectMargin = 100 * time.Millisecond
+
+// OnMediaGroup registers the handler that is called once for all the messages or
+// channel posts of a media group, read them with Context.MediaGroup. The messages
+// are still dispatched one by one to the other handlers.
+func (d *Dispatcher) OnMediaGroup(h Handler) {
+	d.mediaGroupHandlers = append(d.mediaGroupHandlers, h)
+}
+
+// SetMediaGroupWindow sets how long the media group is buffered after its last
+// message, DefaultMediaGroupWindow by default. The window must be positive.
+func (d *Dispatcher) SetMediaGroupWindow(window time.Duration) error {
+	if window <= 0 {
+		return fmt.Errorf("media group window must be positive, got %s", window)
+	}
+
+	d.mediaGroupWindow = window
+
+	return nil
+}
+
+// MediaGroup returns the messages of the media group ordered by the message ids,
+// only available to the handlers registered by OnMediaGroup.
+func (c *Context) MediaGroup() []tgbotapi.Message {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.mediaGroup
+}
+
+func (c *Context) withMediaGroup(messages []tgbotapi.Message) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.mediaGroup = messages
+}
+
+// dispatchMediaGroup buffers the message with the queue shared by the replicas
+// and pushes the deadline of the media group back by the window, the first one
+// that sees the media group collects the buffered messages once no message came
+// in for the window and calls the handlers with them.
+func (d *Dispatcher) dispatchMediaGroup(c *Context, message *tgbotapi.Message) {
+	if len(d.mediaGroupHandlers) == 0 {
+		return
+	}
+
+	messagesKey := redis.MediaGroupMessages3.Format(c.Bot.Self.ID, message.Chat.ID, message.MediaGroupID)
+
+	err := c.Bot.queue.Push(context.Background(), messagesKey, string(lo.Must(json.Marshal(message))))
+	if err != nil {
+		d.logger.Error("failed to buffer media group message",
+			zap.String("media_group_id", message.MediaGroupID),
+			zap.Int("message_id", message.MessageID),
+			zap.Error(err),
+		)
+
+		return
+	}
+
+	// dropped in case the collector never comes, e.g. the replica died meanwhile
+	err = c.Bot.queue.Expire(context.Background(), messagesKey, 2*(d.mediaGroupWindow+mediaGroupCollectMargin))
+	if err != nil {
+		d.logger.Error("failed to expire media group messages", zap.String("media_group_id", message.MediaGroupID), zap.Error(err))
+	}
+
+	deadlineKey := redis.MediaGroupDeadline3.Format(c.Bot.Self.ID, message.Chat.ID, message.MediaGroupID)
+	deadline := time.Now().Add(d.mediaGroupWindow)
+
+	// kept a second longer than the deadline, so that the collector still finds it
+	err = c.Bot.ttlcache.Set(context.Background(), deadlineKey, strconv.FormatInt(deadline.UnixMilli(), 10), d.mediaGroupWindow+mediaGroupCollectMargin+time.Second)
+	if err != nil {
+		// the collector collects right away without the deadline, still elect one
+		// so that the message is not left in the buffer
+		d.logger.Error("failed to push back media group deadline", zap.String("media_group_id", message.MediaGroupID), zap.Error(err))
+	}
+
+	collectorKey := redis.MediaGroupCollector3.Format(c.Bot.Self.ID, message.Chat.ID, message.MediaGroupID)
+
+	collecting, err := c.Bot.ttlcache.SetNX(context.Background(), collectorKey, "1", d.mediaGroupWindow)
+	if err != nil {
+		d.logger.Error("failed to elect media group collector", zap.String("media_group_id", message.MediaGroupID), zap.Error(err))
+		return
+	}
+	if !collecting {
+		return
+	}
+
+	// the collector outlives the handlers of the update, it runs on a context of
+	// its own so that the webhook reply of the update is not held until collected
+	collector := NewContext(c.Bot.BotAPI, c.Bot, c.Update, c.Logger, c.I18n)
+
+	d.dispatchInGoroutine(collector, "media_group", func() {
+		if !d.waitMediaGroupDeadline(collector, deadlineKey) {
+			d.logger.Debug("media group collector was cancelled", zap.String("media_group_id", message.MediaGroupID))
+			return
+		}
+
+		messages, err := collectMediaGroupMessages(collector.Bot, messagesKey)
+		if err != nil {
+			d.logger.Error("failed to collect media group messages", zap.String("media_group_id", message.MediaGroupID), zap.Error(err))
+			return
+		}
+		// collected by the previous collector
+		if len(messages) == 0 {
+			return
+		}
+
+		d.logger.Debug("collected media group",
+			zap.String("media_group_id", message.MediaGroupID),
+			zap.Int("count", len(messages)),
+		)
+
+		collector.withMediaGroup(messages)
+
+		for _, h := range d.mediaGroupHandlers {
+			_, _ = h.Handle(collector)
+		}
+	})
+}
+
+// waitMediaGroupDeadline sleeps until the deadline of the media group passed by
+// mediaGroupCollectMargin, waiting again for the deadlines pushed back meanwhile.
+// The election of the collector expires the window after the deadline was set,
+// the margin makes sure that it expired before collecting. It reports false when
+// the context of the collector is cancelled meanwhile.
+func (d *Dispatcher) waitMediaGroupDeadline(c *Context, deadlineKey string) bool {
+	for {
+		value, err := c.Bot.ttlcache.Get(c.Context(), deadlineKey)
+		if err != nil {
+			d.logger.Error("failed to get media group deadline", zap.Error(err))
+			return c.Context().Err() == nil
+		}
+
+		deadline, err := strconv.ParseInt(value.OrEmpty(), 10, 64)
+		// expired, or failed to be set
+		if err != nil {
+			return true
+		}
+
+		wait := time.Until(time.UnixMilli(deadline).Add(mediaGroupCollectMargin))
+		if wait <= 0 {
+			return true
+		}
+		if !sleepContext(c.Context(), wait) {
+			return false
+		}
+	}
+}
+
+// collectMediaGroupMessages pops the buffered messages, ordered by the message ids
+// and deduplicated in case of the updates delivered more than once.
+func collectMediaGroupMessages(bot *BotAPI, messagesKey string) ([]tgbotapi.Message, error) {
+	values, err := bot.queue.PopAll(context.Background(), messagesKey)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]tgbotapi.Message, 0, len(values))
+
+	for _, value := range values {
+		var message tgbotapi.Message
+
+		err := json.Unmarshal([]byte(value), &message)
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, message)
+	}
+
+	messages = lo.UniqBy(messages, func(message tgbotapi.Message) int {
+		return message.MessageID
+	})
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].MessageID < messages[j].MessageID
+	})
+
+	return messages, nil
+}
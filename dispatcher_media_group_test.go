@@ -0,0 +1,98 @@
+package tgo
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nekomeowww/tgo/pkg/storage/queue"
+	"github.com/nekomeowww/tgo/pkg/storage/ttlcache"
+	"github.com/nekomeowww/xo/logger"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestDispatchMediaGroup(t *testing.T) {
+	logger, err := logger.NewLogger(logger.WithLevel(zapcore.DebugLevel), logger.WithAppName("tgo"), logger.WithNamespace("nekomeowww"))
+	require.NoError(t, err)
+
+	bot := &BotAPI{
+		BotAPI:   &tgbotapi.BotAPI{Self: tgbotapi.User{ID: 1}},
+		logger:   logger,
+		queue:    queue.NewInMemoryQueue(),
+		ttlcache: ttlcache.NewInMemoryTTLCache(),
+	}
+
+	var (
+		mutex  sync.Mutex
+		groups [][]int
+	)
+
+	d := NewDispatcher(logger)
+	require.Error(t, d.SetMediaGroupWindow(0))
+	require.NoError(t, d.SetMediaGroupWindow(50*time.Millisecond))
+	d.OnMediaGroup(NewHandler(func(ctx *Context) (Response, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		groups = append(groups, lo.Map(ctx.MediaGroup(), func(message tgbotapi.Message, _ int) int {
+			return message.MessageID
+		}))
+
+		return nil, nil
+	}))
+
+	message := func(updateID int, messageID int) tgbotapi.Update {
+		return tgbotapi.Update{
+			UpdateID: updateID,
+			Message: &tgbotapi.Message{
+				MessageID:    messageID,
+				From:         &tgbotapi.User{ID: 2, FirstName: "Neko"},
+				Chat:         &tgbotapi.Chat{ID: 2, Type: "private"},
+				MediaGroupID: "album",
+				Photo:        []tgbotapi.PhotoSize{{FileID: "photo"}},
+			},
+		}
+	}
+
+	d.Dispatch(nil, bot, nil, message(1, 12))
+	d.Dispatch(nil, bot, nil, message(2, 11))
+	d.Dispatch(nil, bot, nil, message(2, 11))
+	d.Dispatch(nil, bot, nil, message(3, 13))
+
+	// each message pushes the collecting back, the group is only delivered once
+	// no message came in for the window
+	time.Sleep(30 * time.Millisecond)
+	d.Dispatch(nil, bot, nil, message(4, 14))
+	time.Sleep(30 * time.Millisecond)
+
+	reply := newWebhookReply()
+	d.dispatch(nil, bot, nil, message(5, 15), reply)
+	reply.release()
+
+	// the webhook reply is not held until the group is collected
+	select {
+	case <-reply.done:
+	case <-time.After(25 * time.Millisecond):
+		assert.Fail(t, "webhook reply is held by the media group collector")
+	}
+
+	idle := func() bool {
+		return len(d.InFlightHandlers()) == 0
+	}
+
+	require.Eventually(t, idle, time.Second, 10*time.Millisecond)
+
+	// arrived after the group was delivered
+	d.Dispatch(nil, bot, nil, message(6, 16))
+
+	require.Eventually(t, idle, time.Second, 10*time.Millisecond)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	assert.Equal(t, [][]int{{11, 12, 13, 14, 15}, {16}}, groups)
+}
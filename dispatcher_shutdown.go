@@ -0,0 +1,165 @@
+package tgo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+)
+
+// InFlightHandler describes a handler that was dispatched and has not returned yet.
+type InFlightHandler struct {
+	UpdateID   int
+	UpdateType UpdateType
+	Name       string
+	StartedAt  time.Time
+}
+
+// ShutdownTimeoutError is returned by Dispatcher.Shutdown when the context is done
+// before every in-flight handler returned, Handlers lists the ones that were cut off.
+type ShutdownTimeoutError struct {
+	Handlers []InFlightHandler
+
+	err error
+}
+
+func (e ShutdownTimeoutError) Error() string {
+	names := lo.Map(e.Handlers, func(h InFlightHandler, _ int) string {
+		return fmt.Sprintf("%s (update %d, %s)", h.Name, h.UpdateID, h.UpdateType)
+	})
+
+	return fmt.Sprintf("%v: %d handler(s) still running: %s", e.err, len(e.Handlers), strings.Join(names, ", "))
+}
+
+func (e ShutdownTimeoutError) Unwrap() error {
+	return e.err
+}
+
+type inFlightHandlers struct {
+	mutex sync.Mutex
+	wg    sync.WaitGroup
+
+	draining bool
+	nextID   uint64
+	handlers map[uint64]InFlightHandler
+	cancels  map[uint64]context.CancelFunc
+}
+
+func newInFlightHandlers() *inFlightHandlers {
+	return &inFlightHandlers{
+		handlers: make(map[uint64]InFlightHandler),
+		cancels:  make(map[uint64]context.CancelFunc),
+	}
+}
+
+func (h *inFlightHandlers) add(c *Context, name string) (uint64, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.draining {
+		return 0, false
+	}
+
+	h.nextID++
+	h.handlers[h.nextID] = InFlightHandler{
+		UpdateID:   c.Update.UpdateID,
+		UpdateType: c.UpdateType(),
+		Name:       name,
+		StartedAt:  time.Now(),
+	}
+	h.cancels[h.nextID] = c.cancel
+	h.wg.Add(1)
+
+	return h.nextID, true
+}
+
+func (h *inFlightHandlers) done(id uint64) {
+	h.mutex.Lock()
+	delete(h.handlers, id)
+	delete(h.cancels, id)
+	h.mutex.Unlock()
+
+	h.wg.Done()
+}
+
+func (h *inFlightHandlers) isDraining() bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.draining
+}
+
+func (h *inFlightHandlers) drain() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.draining = true
+}
+
+// cancel cancels the contexts of the handlers that are still running.
+func (h *inFlightHandlers) cancel() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for _, cancel := range h.cancels {
+		cancel()
+	}
+}
+
+func (h *inFlightHandlers) list() []InFlightHandler {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	handlers := lo.Values(h.handlers)
+	sort.Slice(handlers, func(i, j int) bool {
+		return handlers[i].StartedAt.Before(handlers[j].StartedAt)
+	})
+
+	return handlers
+}
+
+// InFlightHandlers returns the handlers that are currently running.
+func (d *Dispatcher) InFlightHandlers() []InFlightHandler {
+	return d.inFlight.list()
+}
+
+// Shutdown stops the dispatcher from accepting new updates and waits for the
+// in-flight handlers to return. If ctx is done before that, the contexts of the
+// handlers that were cut off are cancelled, and a ShutdownTimeoutError listing
+// them is returned.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.inFlight.drain()
+
+	waitDone := make(chan struct{})
+
+	go func() {
+		d.inFlight.wg.Wait()
+		close(waitDone)
+	}()
+
+	select {
+	case <-waitDone:
+		d.logger.Debug("all in-flight handlers returned, dispatcher is shut down")
+
+		return nil
+	case <-ctx.Done():
+		cutOff := d.inFlight.list()
+		for _, h := range cutOff {
+			d.logger.Warn("handler was cut off by shutdown",
+				zap.String("handler", h.Name),
+				zap.Int("update_id", h.UpdateID),
+				zap.String("update_type", string(h.UpdateType)),
+				zap.Duration("running_for", time.Since(h.StartedAt)),
+			)
+		}
+
+		d.inFlight.cancel()
+
+		return ShutdownTimeoutError{Handlers: cutOff, err: ctx.Err()}
+	}
+}
@@ -0,0 +1,111 @@
+package tgo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nekomeowww/xo/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestDispatcherShutdown(t *testing.T) {
+	logger, err := logger.NewLogger(logger.WithLevel(zapcore.DebugLevel), logger.WithAppName("tgo"), logger.WithNamespace("nekomeowww"))
+	require.NoError(t, err)
+
+	t.Run("Drained", func(t *testing.T) {
+		d := NewDispatcher(logger)
+		c := NewContext(nil, nil, tgbotapi.Update{UpdateID: 1, Message: &tgbotapi.Message{}}, logger, nil)
+
+		release := make(chan struct{})
+		d.dispatchInGoroutine(c, "test", func() {
+			<-release
+		})
+
+		require.Len(t, d.InFlightHandlers(), 1)
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		require.NoError(t, d.Shutdown(ctx))
+		assert.Empty(t, d.InFlightHandlers())
+		assert.ErrorIs(t, c.Context().Err(), context.Canceled)
+	})
+
+	t.Run("Dispatched", func(t *testing.T) {
+		d := NewDispatcher(logger)
+
+		var middlewareCtx, handlerCtx *Context
+
+		d.Use(func(ctx *Context, next func()) {
+			middlewareCtx = ctx
+		})
+		d.OnChannelPost(NewHandler(func(ctx *Context) (Response, error) {
+			handlerCtx = ctx
+			return nil, nil
+		}))
+
+		d.Dispatch(nil, nil, nil, tgbotapi.Update{UpdateID: 4, ChannelPost: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 1, Type: "channel"}}})
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		require.NoError(t, d.Shutdown(ctx))
+		require.NotNil(t, middlewareCtx)
+		require.NotNil(t, handlerCtx)
+		assert.ErrorIs(t, middlewareCtx.Context().Err(), context.Canceled)
+		assert.ErrorIs(t, handlerCtx.Context().Err(), context.Canceled)
+	})
+
+	t.Run("CutOff", func(t *testing.T) {
+		d := NewDispatcher(logger)
+		c := NewContext(nil, nil, tgbotapi.Update{UpdateID: 2, Message: &tgbotapi.Message{}}, logger, nil)
+
+		release := make(chan struct{})
+		defer close(release)
+
+		d.dispatchInGoroutine(c, "slow", func() {
+			<-release
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		err := d.Shutdown(ctx)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		var shutdownErr ShutdownTimeoutError
+		require.True(t, errors.As(err, &shutdownErr))
+		require.Len(t, shutdownErr.Handlers, 1)
+		assert.Equal(t, "slow", shutdownErr.Handlers[0].Name)
+		assert.Equal(t, 2, shutdownErr.Handlers[0].UpdateID)
+		assert.Equal(t, UpdateTypeMessage, shutdownErr.Handlers[0].UpdateType)
+		assert.ErrorIs(t, c.Context().Err(), context.Canceled)
+	})
+
+	t.Run("RejectsAfterShutdown", func(t *testing.T) {
+		d := NewDispatcher(logger)
+		c := NewContext(nil, nil, tgbotapi.Update{UpdateID: 3, Message: &tgbotapi.Message{}}, logger, nil)
+
+		require.NoError(t, d.Shutdown(context.Background()))
+
+		called := false
+		d.dispatchInGoroutine(c, "late", func() {
+			called = true
+		})
+
+		assert.False(t, called)
+		assert.Empty(t, d.InFlightHandlers())
+		assert.ErrorIs(t, c.Context().Err(), context.Canceled)
+	})
+}
@@ -0,0 +1,82 @@
+package tgo
+
+import (
+	"testing"
+
+	"github.com/nekomeowww/xo/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestDispatcherAllowedUpdates(t *testing.T) {
+	logger, err := logger.NewLogger(logger.WithLevel(zapcore.DebugLevel), logger.WithAppName("tgo"), logger.WithNamespace("nekomeowww"))
+	require.NoError(t, err)
+
+	nop := NewHandler(func(ctx *Context) (Response, error) {
+		return nil, nil
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		d := NewDispatcher(logger)
+
+		assert.ElementsMatch(t, []string{"message"}, d.AllowedUpdates())
+	})
+
+	t.Run("CallbackQuery", func(t *testing.T) {
+		d := NewDispatcher(logger)
+		d.OnCallbackQuery("action", nop)
+
+		assert.ElementsMatch(t, []string{"message", "callback_query"}, d.AllowedUpdates())
+	})
+
+	t.Run("Registered", func(t *testing.T) {
+		d := NewDispatcher(logger)
+		d.OnChannelPost(nop)
+		d.OnChatMember(nop)
+		d.OnNewChatMember(nop)
+
+		assert.ElementsMatch(t, []string{"message", "channel_post", "chat_member"}, d.AllowedUpdates())
+	})
+
+	t.Run("MediaGroup", func(t *testing.T) {
+		d := NewDispatcher(logger)
+		d.OnMediaGroup(nop)
+
+		assert.ElementsMatch(t, []string{"message", "channel_post"}, d.AllowedUpdates())
+	})
+
+	t.Run("Explicit", func(t *testing.T) {
+		d := NewDispatcher(logger)
+		d.AllowUpdates(UpdateTypeEditedMessage, UpdateTypeChatMigrationTo, UpdateTypeUnknown)
+
+		assert.ElementsMatch(t, []string{"message", "edited_message"}, d.AllowedUpdates())
+	})
+
+	t.Run("Middlewares", func(t *testing.T) {
+		d := NewDispatcher(logger)
+		d.Use(func(ctx *Context, next func()) {})
+		d.OnChatMember(nop)
+
+		assert.ElementsMatch(t, []string{
+			"message",
+			"edited_message",
+			"channel_post",
+			"edited_channel_post",
+			"inline_query",
+			"chosen_inline_result",
+			"callback_query",
+			"shipping_query",
+			"pre_checkout_query",
+			"poll",
+			"poll_answer",
+			"my_chat_member",
+			"chat_join_request",
+			"chat_member",
+		}, d.AllowedUpdates())
+
+		d.AllowUpdates(UpdateTypeEditedMessage)
+
+		assert.ElementsMatch(t, []string{"message", "chat_member", "edited_message"}, d.AllowedUpdates())
+	})
+}
@@ -1,6 +1,9 @@
 package tgo
 
-import tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/samber/lo"
+)
 
 type PinChatMessageConfig struct {
 	ChatID              int64
@@ -22,7 +25,7 @@ func (config PinChatMessageConfig) params() (tgbotapi.Params, error) {
 	}
 
 	params.AddNonZero("message_id", config.MessageID)
-	params.AddBool("disable_notification", true)
+	params.AddBool("disable_notification", config.DisableNotification)
 
 	return params, err
 }
@@ -64,3 +67,97 @@ func NewUnpinChatMessageConfig(chatID int64, messageID int) UnpinChatMessageConf
 		MessageID: messageID,
 	}
 }
+
+// ReactionType is the reaction set on a message, an emoji or a custom emoji.
+type ReactionType struct {
+	Type          string `json:"type"`
+	Emoji         string `json:"emoji,omitempty"`
+	CustomEmojiID string `json:"custom_emoji_id,omitempty"`
+}
+
+// SetMessageReactionConfig is the setMessageReaction request, which tgbotapi lacks.
+type SetMessageReactionConfig struct {
+	ChatID          int64
+	ChannelUsername string
+	MessageID       int
+	Reaction        []ReactionType
+	IsBig           bool
+}
+
+func (config SetMessageReactionConfig) method() string {
+	return "setMessageReaction"
+}
+
+func (config SetMessageReactionConfig) params() (tgbotapi.Params, error) {
+	params := make(tgbotapi.Params)
+
+	err := params.AddFirstValid("chat_id", config.ChatID, config.ChannelUsername)
+	if err != nil {
+		return nil, err
+	}
+
+	params.AddNonZero("message_id", config.MessageID)
+
+	// an empty reaction removes the reactions of the bot
+	err = params.AddInterface("reaction", lo.Ternary(config.Reaction == nil, []ReactionType{}, config.Reaction))
+	if err != nil {
+		return nil, err
+	}
+
+	params.AddBool("is_big", config.IsBig)
+
+	return params, nil
+}
+
+// NewSetMessageReactionConfig reacts to the message with the emojis, no emojis
+// remove the reactions.
+func NewSetMessageReactionConfig(chatID int64, messageID int, emojis ...string) SetMessageReactionConfig {
+	return SetMessageReactionConfig{
+		ChatID:    chatID,
+		MessageID: messageID,
+		Reaction: lo.Map(emojis, func(emoji string, _ int) ReactionType {
+			return ReactionType{Type: "emoji", Emoji: emoji}
+		}),
+	}
+}
+
+// SetWebhookConfig is the setWebhook request with the parameters that tgbotapi.WebhookConfig lacks.
+type SetWebhookConfig struct {
+	URL                string
+	Certificate        tgbotapi.RequestFileData
+	IPAddress          string
+	MaxConnections     int
+	AllowedUpdates     []string
+	DropPendingUpdates bool
+	SecretToken        string
+}
+
+func (config SetWebhookConfig) method() string {
+	return "setWebhook"
+}
+
+func (config SetWebhookConfig) params() (tgbotapi.Params, error) {
+	params := make(tgbotapi.Params)
+
+	params["url"] = config.URL
+	params.AddNonEmpty("ip_address", config.IPAddress)
+	params.AddNonZero("max_connections", config.MaxConnections)
+
+	err := params.AddInterface("allowed_updates", config.AllowedUpdates)
+	if err != nil {
+		return nil, err
+	}
+
+	params.AddBool("drop_pending_updates", config.DropPendingUpdates)
+	params.AddNonEmpty("secret_token", config.SecretToken)
+
+	return params, nil
+}
+
+func (config SetWebhookConfig) files() []tgbotapi.RequestFile {
+	if config.Certificate == nil {
+		return nil
+	}
+
+	return []tgbotapi.RequestFile{{Name: "certificate", Data: config.Certificate}}
+}
@@ -0,0 +1,134 @@
+package tgo
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"github.com/nekomeowww/xo/logger"
+)
+
+// Errors of the common failures of the Bot API, match them with errors.Is against
+// the errors returned by BotAPI.Send and BotAPI.Request, or the errors wrapped by
+// NewBotAPIError.
+var (
+	ErrMessageNotModified         = errors.New("message is not modified")
+	ErrMessageToEditNotFound      = errors.New("message to edit not found")
+	ErrMessageToDeleteNotFound    = errors.New("message to delete not found")
+	ErrMessageCantBeDeleted       = errors.New("message can't be deleted")
+	ErrChatNotFound               = errors.New("chat not found")
+	ErrNotEnoughRights            = errors.New("not enough rights")
+	ErrUserDeactivated            = errors.New("user is deactivated")
+	ErrBotWasBlockedByTheUser     = errors.New("bot was blocked by the user")
+	ErrCannotInitiateChatWithUser = errors.New("bot can't initiate conversation with a user")
+	ErrChatMigrated               = errors.New("group chat was upgraded to a supergroup chat")
+	ErrTooManyRequests            = errors.New("too many requests")
+)
+
+// BotAPIError is the failure responded by the Bot API, extract it with errors.As
+// for the parameters such as MigrateToChatID and RetryAfter. It also matches the
+// *tgbotapi.Error it was made of.
+type BotAPIError struct {
+	Code        int
+	Description string
+	// MigrateToChatID is the id of the supergroup that the group migrated to,
+	// set along with ErrChatMigrated.
+	MigrateToChatID int64
+	// RetryAfter is how long to wait before the request can be repeated, set
+	// along with ErrTooManyRequests.
+	RetryAfter time.Duration
+
+	kind error
+	err  *tgbotapi.Error
+}
+
+// NewBotAPIError wraps the *tgbotapi.Error in err into a *BotAPIError, other
+// errors are returned as they are.
+func NewBotAPIError(err error) error {
+	var botAPIErr *BotAPIError
+	if errors.As(err, &botAPIErr) {
+		return err
+	}
+
+	var tgbotapiErr *tgbotapi.Error
+	if !errors.As(err, &tgbotapiErr) {
+		return err
+	}
+
+	return &BotAPIError{
+		Code:            tgbotapiErr.Code,
+		Description:     tgbotapiErr.Message,
+		MigrateToChatID: tgbotapiErr.MigrateToChatID,
+		RetryAfter:      time.Duration(tgbotapiErr.RetryAfter) * time.Second,
+		kind:            botAPIErrorKindOf(tgbotapiErr),
+		err:             tgbotapiErr,
+	}
+}
+
+func (e *BotAPIError) Error() string {
+	return e.err.Error()
+}
+
+func (e *BotAPIError) Unwrap() []error {
+	if e.kind == nil {
+		return []error{e.err}
+	}
+
+	return []error{e.kind, e.err}
+}
+
+func botAPIErrorKindOf(err *tgbotapi.Error) error {
+	description := strings.ToLower(err.Message)
+
+	switch {
+	case err.Code == 429:
+		return ErrTooManyRequests
+	case err.MigrateToChatID != 0:
+		return ErrChatMigrated
+	case strings.Contains(description, "message is not modified"):
+		return ErrMessageNotModified
+	case strings.Contains(description, "message to edit not found"):
+		return ErrMessageToEditNotFound
+	case strings.Contains(description, "message to delete not found"):
+		return ErrMessageToDeleteNotFound
+	case strings.Contains(description, "message can't be deleted"):
+		return ErrMessageCantBeDeleted
+	case strings.Contains(description, "chat not found"):
+		return ErrChatNotFound
+	case strings.Contains(description, "not enough rights"),
+		strings.Contains(description, "have no rights"):
+		return ErrNotEnoughRights
+	case strings.Contains(description, "user is deactivated"):
+		return ErrUserDeactivated
+	case strings.Contains(description, "bot was blocked by the user"):
+		return ErrBotWasBlockedByTheUser
+	case strings.Contains(description, "bot can't initiate conversation with a user"):
+		return ErrCannotInitiateChatWithUser
+	default:
+		return nil
+	}
+}
+
+// logBotAPIError logs the failed request at the level by how much it matters, the
+// edits that change nothing are expected, and the users that blocked or deleted
+// the bot can't be helped.
+func logBotAPIError(logger *logger.Logger, msg string, err error, fields ...zap.Field) {
+	fields = append(fields, zap.Error(err))
+
+	switch {
+	case errors.Is(err, ErrMessageNotModified):
+		logger.Debug(msg, fields...)
+	case errors.Is(err, ErrBotWasBlockedByTheUser),
+		errors.Is(err, ErrCannotInitiateChatWithUser),
+		errors.Is(err, ErrUserDeactivated),
+		errors.Is(err, ErrMessageToEditNotFound),
+		errors.Is(err, ErrMessageToDeleteNotFound),
+		errors.Is(err, ErrMessageCantBeDeleted):
+		logger.Warn(msg, fields...)
+	default:
+		logger.Error(msg, fields...)
+	}
+}
@@ -0,0 +1,109 @@
+package tgo
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBotAPIError(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  *tgbotapi.Error
+		kind error
+	}{
+		{
+			name: "MessageNotModified",
+			err:  &tgbotapi.Error{Code: 400, Message: "Bad Request: message is not modified: specified new message content and reply markup are exactly the same as a current content and reply markup of the message"},
+			kind: ErrMessageNotModified,
+		},
+		{
+			name: "MessageToEditNotFound",
+			err:  &tgbotapi.Error{Code: 400, Message: "Bad Request: message to edit not found"},
+			kind: ErrMessageToEditNotFound,
+		},
+		{
+			name: "ChatNotFound",
+			err:  &tgbotapi.Error{Code: 400, Message: "Bad Request: chat not found"},
+			kind: ErrChatNotFound,
+		},
+		{
+			name: "NotEnoughRights",
+			err:  &tgbotapi.Error{Code: 400, Message: "Bad Request: not enough rights to send text messages to the chat"},
+			kind: ErrNotEnoughRights,
+		},
+		{
+			name: "HaveNoRights",
+			err:  &tgbotapi.Error{Code: 400, Message: "Bad Request: have no rights to send a message"},
+			kind: ErrNotEnoughRights,
+		},
+		{
+			name: "UserDeactivated",
+			err:  &tgbotapi.Error{Code: 403, Message: "Forbidden: user is deactivated"},
+			kind: ErrUserDeactivated,
+		},
+		{
+			name: "BotWasBlockedByTheUser",
+			err:  &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"},
+			kind: ErrBotWasBlockedByTheUser,
+		},
+		{
+			name: "CannotInitiateChatWithUser",
+			err:  &tgbotapi.Error{Code: 403, Message: "Forbidden: bot can't initiate conversation with a user"},
+			kind: ErrCannotInitiateChatWithUser,
+		},
+		{
+			name: "ChatMigrated",
+			err:  &tgbotapi.Error{Code: 400, Message: "Bad Request: group chat was upgraded to a supergroup chat", ResponseParameters: tgbotapi.ResponseParameters{MigrateToChatID: -100123}},
+			kind: ErrChatMigrated,
+		},
+		{
+			name: "TooManyRequests",
+			err:  &tgbotapi.Error{Code: 429, Message: "Too Many Requests: retry after 7", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 7}},
+			kind: ErrTooManyRequests,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := NewBotAPIError(tc.err)
+
+			assert.ErrorIs(t, err, tc.kind)
+			assert.Equal(t, tc.err.Message, err.Error())
+
+			var tgbotapiErr *tgbotapi.Error
+			require.True(t, errors.As(err, &tgbotapiErr))
+			assert.Equal(t, tc.err, tgbotapiErr)
+		})
+	}
+
+	t.Run("Parameters", func(t *testing.T) {
+		err := NewBotAPIError(&tgbotapi.Error{Code: 429, ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 7, MigrateToChatID: -100123}})
+
+		var botAPIErr *BotAPIError
+		require.True(t, errors.As(err, &botAPIErr))
+		assert.Equal(t, 7*time.Second, botAPIErr.RetryAfter)
+		assert.Equal(t, int64(-100123), botAPIErr.MigrateToChatID)
+		assert.Same(t, err, NewBotAPIError(err))
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		err := NewBotAPIError(&tgbotapi.Error{Code: 400, Message: "Bad Request: something else"})
+		assert.NotErrorIs(t, err, ErrChatNotFound)
+
+		var botAPIErr *BotAPIError
+		require.True(t, errors.As(err, &botAPIErr))
+		assert.Equal(t, 400, botAPIErr.Code)
+	})
+
+	t.Run("NotBotAPIError", func(t *testing.T) {
+		assert.NoError(t, NewBotAPIError(nil))
+
+		err := errors.New("network")
+		assert.Same(t, err, NewBotAPIError(err))
+	})
+}
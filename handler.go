@@ -2,9 +2,12 @@ package tgo
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nekomeowww/xo"
 	"github.com/samber/lo"
 	"go.uber.org/zap"
 )
@@ -188,80 +191,349 @@ func processError(ctx *Context, err error) Response {
 	}
 }
 
+// Responses are executed in order, e.g. deleting the command, editing a status
+// message and sending the result. A failed response is logged and the rest are
+// still executed.
+type Responses []Response
+
+// ErrUnknownResponse is returned for the responses that can't be executed.
+var ErrUnknownResponse = errors.New("unknown response")
+
 func processResponse(ctx *Context, resp Response) {
 	if resp == nil {
 		return
 	}
 
+	messages, _ := executeResponse(ctx, resp)
+	ctx.withLastSentMessages(messages)
+}
+
+// executeResponse executes the response, returns the sent messages, the failures
+// are logged and returned.
+func executeResponse(ctx *Context, resp Response) ([]tgbotapi.Message, error) {
+	if resp == nil {
+		return nil, nil
+	}
+	if responses, ok := resp.(Responses); ok {
+		return executeResponses(ctx, responses)
+	}
+
+	var (
+		messages []tgbotapi.Message
+		err      error
+	)
+
+	accepted, previous := ctx.webhookReply.offer(webhookReplyChattable(resp))
+	if previous != nil {
+		// the reply carried so far is displaced by this one, send it on its own
+		messages, err = executeChattable(ctx, previous)
+	}
+	if accepted {
+		ctx.Abort()
+		return messages, err
+	}
+
+	sent, respErr := executeSingleResponse(ctx, resp)
+
+	return append(messages, sent...), errors.Join(err, respErr)
+}
+
+func executeSingleResponse(ctx *Context, resp Response) ([]tgbotapi.Message, error) {
 	switch v := resp.(type) {
 	case MessageResponse:
 		ctx.Abort()
-
-		msg := ctx.Bot.MaySend(v.messageConfig)
-		if msg != nil && v.deleteLaterForUserID != 0 && v.deleteLaterChatID != 0 {
-			err := ctx.Bot.PushOneDeleteLaterMessage(v.deleteLaterForUserID, v.deleteLaterChatID, msg.MessageID)
-			if err != nil {
-				ctx.Logger.Error("failed to push delete later message", zap.Error(err))
-			}
-		}
+		return sendMessageResponse(ctx, v)
+	case MediaResponse:
+		ctx.Abort()
+		return sendMediaResponse(ctx, v)
+	case MediaGroupResponse:
+		ctx.Abort()
+		return sendMediaGroupResponse(ctx, v)
 	case EditMessageResponse:
 		ctx.Abort()
+		return nil, editMessageResponse(ctx, v)
+	case ActionResponse:
+		ctx.Abort()
+		return executeActionResponse(ctx, v)
+	case tgbotapi.Chattable:
+		ctx.Abort()
+		return executeChattable(ctx, v)
+	default:
+		ctx.Logger.Error(fmt.Sprintf("encountered unknown response %T", v),
+			zap.String("request", string(lo.Must(json.Marshal(v)))),
+			zap.Int64("chat_id", ctx.Update.FromChat().ID),
+		)
 
-		if v.mediaConfig != nil {
-			_, err := ctx.Bot.Request(v.mediaConfig)
-			if err != nil {
-				ctx.Logger.Error("failed to edit message",
-					zap.Error(err),
-					zap.Any("request", v.mediaConfig),
-					zap.Int64("chat_id", ctx.Update.FromChat().ID),
-				)
-			}
-		}
-		if v.replyMarkupConfig != nil {
-			_, err := ctx.Bot.Request(v.replyMarkupConfig)
-			if err != nil {
-				ctx.Logger.Error("failed to edit message",
-					zap.Error(err),
-					zap.Any("request", v.replyMarkupConfig),
-					zap.Int64("chat_id", ctx.Update.FromChat().ID),
-				)
-			}
+		return nil, fmt.Errorf("%w: %T", ErrUnknownResponse, v)
+	}
+}
+
+func executeResponses(ctx *Context, responses Responses) ([]tgbotapi.Message, error) {
+	messages := make([]tgbotapi.Message, 0)
+	errs := make([]error, 0)
+
+	for i, resp := range responses {
+		sent, err := executeResponse(ctx, resp)
+
+		messages = append(messages, sent...)
+
+		if err != nil {
+			ctx.Logger.Warn("failed to execute one of the responses, continuing with the rest",
+				zap.Int("index", i),
+				zap.Int("count", len(responses)),
+				zap.Error(err),
+			)
+
+			errs = append(errs, err)
 		}
-		if v.liveLocationConfig != nil {
-			_, err := ctx.Bot.Request(v.liveLocationConfig)
-			if err != nil {
-				ctx.Logger.Error("failed to edit message",
-					zap.Error(err),
-					zap.Any("request", v.liveLocationConfig),
-					zap.Int64("chat_id", ctx.Update.FromChat().ID),
-				)
-			}
+	}
+
+	return messages, errors.Join(errs...)
+}
+
+// sendMessageResponse sends the message, the parts of the overlong text are
+// threaded as replies.
+func sendMessageResponse(ctx *Context, resp MessageResponse) ([]tgbotapi.Message, error) {
+	messages := make([]tgbotapi.Message, 0, 1)
+
+	for _, config := range resp.messageConfigs() {
+		if len(messages) > 0 {
+			config.ReplyToMessageID = messages[len(messages)-1].MessageID
 		}
-		if v.textConfig != nil {
-			_, err := ctx.Bot.Request(v.textConfig)
-			if err != nil {
-				ctx.Logger.Error("failed to edit message",
-					zap.Error(err),
-					zap.Any("request", v.textConfig),
-					zap.Int64("chat_id", ctx.Update.FromChat().ID),
-				)
-			}
+
+		msg, err := sendChattable(ctx, config)
+		if err != nil {
+			return messages, err
 		}
-		if v.captionConfig != nil {
-			_, err := ctx.Bot.Request(v.captionConfig)
-			if err != nil {
-				ctx.Logger.Error("failed to edit message",
-					zap.Error(err),
-					zap.Any("request", v.captionConfig),
-					zap.Int64("chat_id", ctx.Update.FromChat().ID),
-				)
-			}
+
+		pushDeleteLaterMessage(ctx, resp.deleteLaterForUserID, resp.deleteLaterChatID, msg.MessageID)
+		notifySent(ctx, resp.onSent, msg)
+
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+// sendMediaResponse sends the media, followed by the caption as a reply when it's
+// too long to be a caption.
+func sendMediaResponse(ctx *Context, resp MediaResponse) ([]tgbotapi.Message, error) {
+	chattable, captionOverflow := resp.chattable()
+
+	callOpts := make([]RequestCallOption, 0, 1)
+
+	// the reader can't be read again for the retries
+	if _, ok := resp.file().(tgbotapi.FileReader); ok {
+		callOpts = append(callOpts, WithoutRequestRetry())
+	}
+
+	msg, err := sendChattable(ctx, chattable, callOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	pushDeleteLaterMessage(ctx, resp.deleteLaterForUserID, resp.deleteLaterChatID, msg.MessageID)
+	notifySent(ctx, resp.onSent, msg)
+
+	if captionOverflow == nil {
+		return []tgbotapi.Message{msg}, nil
+	}
+
+	captionOverflow.messageConfig.ReplyToMessageID = msg.MessageID
+
+	messages, err := sendMessageResponse(ctx, *captionOverflow)
+
+	return append([]tgbotapi.Message{msg}, messages...), err
+}
+
+// sendMediaGroupResponse sends the media group, returns all the sent messages.
+func sendMediaGroupResponse(ctx *Context, resp MediaGroupResponse) ([]tgbotapi.Message, error) {
+	config, err := resp.MediaGroupConfig()
+	if err != nil {
+		ctx.Logger.Error("invalid media group", zap.Error(err), zap.Int64("chat_id", ctx.Update.FromChat().ID))
+		return nil, err
+	}
+
+	// the readers can't be read again for the retries
+	policy := ctx.Bot.retryPolicy
+	if _, fromReader := resp.needsUpload(); fromReader {
+		policy = NoRetry
+	}
+
+	messages, err := withRetry(ctx.Context(), ctx.Bot.logger, policy, func() ([]tgbotapi.Message, error) {
+		return ctx.Bot.SendMediaGroup(config)
+	})
+	if err != nil {
+		logBotAPIError(ctx.Logger, "failed to send media group to telegram", err, zap.String("message", xo.SprintJSON(config)))
+		return nil, err
+	}
+
+	for _, msg := range messages {
+		pushDeleteLaterMessage(ctx, resp.deleteLaterForUserID, resp.deleteLaterChatID, msg.MessageID)
+		notifySent(ctx, resp.onSent, msg)
+	}
+
+	return messages, nil
+}
+
+// executeChattable sends the chattables that send messages like the typed
+// responses do, the other chattables are requested like ActionResponse.
+func executeChattable(ctx *Context, chattable tgbotapi.Chattable) ([]tgbotapi.Message, error) {
+	if config, ok := chattable.(tgbotapi.MediaGroupConfig); ok {
+		messages, err := withRetry(ctx.Context(), ctx.Bot.logger, ctx.Bot.retryPolicy, func() ([]tgbotapi.Message, error) {
+			return ctx.Bot.SendMediaGroup(config)
+		})
+		if err != nil {
+			logBotAPIError(ctx.Logger, "failed to send media group to telegram", err, zap.String("message", xo.SprintJSON(config)))
+			return nil, err
 		}
+
+		return messages, nil
+	}
+	if !isSendChattable(chattable) {
+		return executeActionResponse(ctx, ActionResponse{chattable: chattable})
+	}
+
+	msg, err := sendChattable(ctx, chattable)
+	if err != nil {
+		return nil, err
+	}
+
+	return []tgbotapi.Message{msg}, nil
+}
+
+// executeActionResponse requests the method, returns the forwarded or copied
+// message if the method responds with one.
+func executeActionResponse(ctx *Context, resp ActionResponse) ([]tgbotapi.Message, error) {
+	var (
+		apiResp *tgbotapi.APIResponse
+		err     error
+	)
+
+	switch {
+	case resp.chattable != nil:
+		apiResp, err = requestChattable(ctx, resp.chattable)
+	case resp.endpoint != nil:
+		apiResp, err = requestEndpoint(ctx, resp.endpoint)
 	default:
-		ctx.Logger.Error(fmt.Sprintf("encountered unknown response %T", v),
-			zap.String("request", string(lo.Must(json.Marshal(v)))),
-			zap.Int64("chat_id", ctx.Update.FromChat().ID),
-		)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	msg, ok := messageOfAPIResponse(apiResp)
+	if !ok {
+		return nil, nil
+	}
+
+	notifySent(ctx, resp.onSent, msg)
+
+	return []tgbotapi.Message{msg}, nil
+}
+
+func editMessageResponse(ctx *Context, resp EditMessageResponse) error {
+	errs := make([]error, 0)
+
+	for _, chattable := range resp.chattables() {
+		_, err := withRetry(ctx.Context(), ctx.Bot.logger, ctx.Bot.retryPolicy, func() (*tgbotapi.APIResponse, error) {
+			return ctx.Bot.Request(chattable)
+		})
+		if err != nil {
+			logBotAPIError(ctx.Logger, "failed to edit message", err,
+				zap.Any("request", chattable),
+				zap.Int64("chat_id", ctx.Update.FromChat().ID),
+			)
+
+			errs = append(errs, err)
+		}
+		// the rest of the edits won't find the message either
+		if errors.Is(err, ErrMessageToEditNotFound) {
+			break
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// sendChattable sends the chattable with the retry policy of the bot, the
+// failures are logged.
+func sendChattable(ctx *Context, chattable tgbotapi.Chattable, callOpts ...RequestCallOption) (tgbotapi.Message, error) {
+	msg, err := withRetry(ctx.Context(), ctx.Bot.logger, retryPolicyOf(ctx.Bot.retryPolicy, callOpts), func() (tgbotapi.Message, error) {
+		return ctx.Bot.Send(chattable)
+	})
+	if err != nil {
+		logBotAPIError(ctx.Logger, "failed to send message to telegram", err, zap.String("message", xo.SprintJSON(chattable)))
+	}
+
+	return msg, err
+}
+
+// requestChattable requests the chattable with the retry policy of the bot, the
+// failures are logged.
+func requestChattable(ctx *Context, chattable tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	resp, err := withRetry(ctx.Context(), ctx.Bot.logger, ctx.Bot.retryPolicy, func() (*tgbotapi.APIResponse, error) {
+		return ctx.Bot.Request(chattable)
+	})
+	if err != nil {
+		logBotAPIError(ctx.Logger, "failed to send request to telegram", err, zap.String("request", xo.SprintJSON(chattable)))
+	}
+
+	return resp, err
+}
+
+// requestEndpoint requests the method that tgbotapi lacks a config for with the
+// retry policy of the bot, the failures are logged.
+func requestEndpoint(ctx *Context, config endpointConfig) (*tgbotapi.APIResponse, error) {
+	params, err := config.params()
+	if err != nil {
+		ctx.Logger.Error("failed to build request to telegram endpoint: "+config.method(), zap.Error(err))
+		return nil, err
+	}
+
+	resp, err := withRetry(ctx.Context(), ctx.Bot.logger, ctx.Bot.retryPolicy, func() (*tgbotapi.APIResponse, error) {
+		return ctx.Bot.MakeRequest(config.method(), params)
+	})
+	if err != nil {
+		logBotAPIError(ctx.Logger, "failed to send request to telegram endpoint: "+config.method(), err, zap.String("request", xo.SprintJSON(params)))
+	}
+
+	return resp, err
+}
+
+// messageOfAPIResponse returns the message that the method responded with, e.g.
+// the sent message, or the id of the copied message.
+func messageOfAPIResponse(resp *tgbotapi.APIResponse) (tgbotapi.Message, bool) {
+	if resp == nil || !strings.HasPrefix(strings.TrimSpace(string(resp.Result)), "{") {
+		return tgbotapi.Message{}, false
+	}
+
+	var msg tgbotapi.Message
+
+	err := json.Unmarshal(resp.Result, &msg)
+	if err != nil || msg.MessageID == 0 {
+		return tgbotapi.Message{}, false
+	}
+
+	return msg, true
+}
+
+func notifySent(ctx *Context, onSent func(c *Context, message *tgbotapi.Message), msg tgbotapi.Message) {
+	if onSent == nil {
+		return
+	}
+
+	onSent(ctx, &msg)
+}
+
+func pushDeleteLaterMessage(ctx *Context, forUserID int64, chatID int64, messageID int) {
+	if forUserID == 0 || chatID == 0 {
+		return
+	}
+
+	err := ctx.Bot.PushOneDeleteLaterMessage(forUserID, chatID, messageID)
+	if err != nil {
+		ctx.Logger.Error("failed to push delete later message", zap.Error(err))
 	}
 }
 
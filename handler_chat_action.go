@@ -0,0 +1,85 @@
+package tgo
+
+import (
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// ChatActionRefreshInterval is how often the chat action is sent again while the
+// handler is running, Telegram shows the action for 5 seconds at most.
+const ChatActionRefreshInterval = 4 * time.Second
+
+// WithChatAction wraps the handle func so that the chat action, one of
+// tgbotapi.ChatTyping, tgbotapi.ChatUploadPhoto and the likes, is shown to the
+// chat of the update until the handle func returns, before the response is sent.
+//
+//	dispatcher.OnCommand("summarize", nil, tgo.NewHandler(tgo.WithChatAction(tgbotapi.ChatTyping, summarize)))
+func WithChatAction(action string, h HandleFunc) HandleFunc {
+	return func(ctx *Context) (Response, error) {
+		stop := ctx.KeepChatAction(action)
+		defer stop()
+
+		return h(ctx)
+	}
+}
+
+// KeepChatAction sends the chat action to the chat of the update, and refreshes it
+// every ChatActionRefreshInterval until the returned stop func is called or the
+// context of the update is cancelled. Stop waits for the action being sent, so that
+// it doesn't show up after the response.
+func (c *Context) KeepChatAction(action string) (stop func()) {
+	return c.keepChatAction(action, ChatActionRefreshInterval)
+}
+
+func (c *Context) keepChatAction(action string, interval time.Duration) func() {
+	chat := c.Update.FromChat()
+	if chat == nil {
+		return func() {}
+	}
+
+	stopped := make(chan struct{})
+	exited := make(chan struct{})
+
+	go func() {
+		defer close(exited)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			c.sendChatAction(chat.ID, action)
+
+			select {
+			case <-stopped:
+				return
+			case <-c.Context().Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			close(stopped)
+			<-exited
+		})
+	}
+}
+
+// sendChatAction sends the chat action without retrying, the next refresh sends it
+// again anyway.
+func (c *Context) sendChatAction(chatID int64, action string) {
+	_, err := c.Bot.Request(tgbotapi.NewChatAction(chatID, action))
+	if err != nil {
+		logBotAPIError(c.Logger, "failed to send chat action", err,
+			zap.Int64("chat_id", chatID),
+			zap.String("action", action),
+		)
+	}
+}
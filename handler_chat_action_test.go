@@ -0,0 +1,75 @@
+package tgo
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeepChatAction(t *testing.T) {
+	t.Run("Refreshed", func(t *testing.T) {
+		ctx, fake := newTestContext(t, func(string, *http.Request) (any, *tgbotapi.APIResponse) {
+			return true, nil
+		})
+
+		stop := ctx.keepChatAction(tgbotapi.ChatTyping, 20*time.Millisecond)
+
+		require.Eventually(t, func() bool {
+			return len(fake.calledMethods()) >= 3
+		}, time.Second, 5*time.Millisecond)
+
+		stop()
+		stop()
+
+		called := len(fake.calledMethods())
+		time.Sleep(50 * time.Millisecond)
+
+		assert.Len(t, fake.calledMethods(), called)
+		assert.Equal(t, []string{"sendChatAction"}, lo.Uniq(fake.calledMethods()))
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, fake := newTestContext(t, func(string, *http.Request) (any, *tgbotapi.APIResponse) {
+			return true, nil
+		})
+
+		stop := ctx.keepChatAction(tgbotapi.ChatUploadPhoto, 20*time.Millisecond)
+		defer stop()
+
+		require.Eventually(t, func() bool {
+			return len(fake.calledMethods()) >= 1
+		}, time.Second, 5*time.Millisecond)
+
+		ctx.cancel()
+		time.Sleep(30 * time.Millisecond)
+
+		called := len(fake.calledMethods())
+		time.Sleep(50 * time.Millisecond)
+
+		assert.Len(t, fake.calledMethods(), called)
+	})
+}
+
+func TestWithChatAction(t *testing.T) {
+	ctx, fake := newTestContext(t, func(method string, _ *http.Request) (any, *tgbotapi.APIResponse) {
+		if method == "sendMessage" {
+			return sentMessageResult(11), nil
+		}
+
+		return true, nil
+	})
+
+	handler := NewHandler(WithChatAction(tgbotapi.ChatTyping, func(c *Context) (Response, error) {
+		return c.NewMessage("done"), nil
+	}))
+
+	_, err := handler.Handle(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"sendChatAction", "sendMessage"}, fake.calledMethods())
+}
@@ -0,0 +1,205 @@
+package tgo
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nekomeowww/tgo/pkg/storage/queue"
+	"github.com/nekomeowww/tgo/pkg/storage/ttlcache"
+	"github.com/nekomeowww/xo/logger"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// fakeBotAPI responds to the Bot API methods with the results returned by respond,
+// and records the called methods in order.
+type fakeBotAPI struct {
+	mutex   sync.Mutex
+	methods []string
+}
+
+func (f *fakeBotAPI) calledMethods() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return append([]string{}, f.methods...)
+}
+
+func newTestContext(t *testing.T, respond func(method string, r *http.Request) (any, *tgbotapi.APIResponse)) (*Context, *fakeBotAPI) {
+	t.Helper()
+
+	logger, err := logger.NewLogger(logger.WithLevel(zapcore.DebugLevel), logger.WithAppName("tgo"), logger.WithNamespace("nekomeowww"))
+	require.NoError(t, err)
+
+	fake := &fakeBotAPI{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+		fake.mutex.Lock()
+		fake.methods = append(fake.methods, method)
+		fake.mutex.Unlock()
+
+		result, failure := respond(method, r)
+		if failure != nil {
+			_ = json.NewEncoder(w).Encode(failure)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+	}))
+	t.Cleanup(server.Close)
+
+	tgbot := &tgbotapi.BotAPI{Token: "token", Client: server.Client(), Self: tgbotapi.User{ID: 1, IsBot: true}, Buffer: 100}
+	tgbot.SetAPIEndpoint(server.URL + "/bot%s/%s")
+
+	bot := &BotAPI{
+		BotAPI:      tgbot,
+		logger:      logger,
+		queue:       queue.NewInMemoryQueue(),
+		ttlcache:    ttlcache.NewInMemoryTTLCache(),
+		retryPolicy: NoRetry,
+	}
+
+	update := tgbotapi.Update{
+		UpdateID: 1,
+		Message: &tgbotapi.Message{
+			MessageID: 10,
+			From:      &tgbotapi.User{ID: 2, FirstName: "Neko"},
+			Chat:      &tgbotapi.Chat{ID: 2, Type: "private"},
+			Text:      "/start",
+		},
+	}
+
+	return NewContext(tgbot, bot, update, logger, nil), fake
+}
+
+func sentMessageResult(messageID int) map[string]any {
+	return map[string]any{"message_id": messageID, "chat": map[string]any{"id": 2, "type": "private"}, "date": 0}
+}
+
+func TestExecuteResponses(t *testing.T) {
+	ctx, fake := newTestContext(t, func(method string, _ *http.Request) (any, *tgbotapi.APIResponse) {
+		switch method {
+		case "sendMessage":
+			return sentMessageResult(11), nil
+		case "deleteMessage":
+			return nil, &tgbotapi.APIResponse{Ok: false, ErrorCode: 400, Description: "Bad Request: message to delete not found"}
+		default:
+			return true, nil
+		}
+	})
+
+	messages, err := executeResponse(ctx, Responses{
+		tgbotapi.NewDeleteMessage(2, 10),
+		ctx.NewEditMessageText(11, "status"),
+		nil,
+		ctx.NewMessage("result"),
+		struct{}{},
+	})
+	require.Error(t, err)
+	require.ErrorIs(t, err, ErrUnknownResponse)
+
+	var botAPIErr *BotAPIError
+	require.True(t, errors.As(err, &botAPIErr))
+	assert.Equal(t, 400, botAPIErr.Code)
+
+	assert.Equal(t, []string{"deleteMessage", "editMessageText", "sendMessage"}, fake.calledMethods())
+	assert.True(t, ctx.IsAborted())
+	require.Len(t, messages, 1)
+	assert.Equal(t, 11, messages[0].MessageID)
+}
+
+func TestExecuteChattableResponses(t *testing.T) {
+	ctx, fake := newTestContext(t, func(method string, _ *http.Request) (any, *tgbotapi.APIResponse) {
+		switch method {
+		case "sendMessage":
+			return sentMessageResult(11), nil
+		case "sendMediaGroup":
+			return []any{sentMessageResult(12), sentMessageResult(13)}, nil
+		default:
+			return true, nil
+		}
+	})
+
+	messages, err := executeResponse(ctx, Responses{
+		tgbotapi.NewMessage(2, "result"),
+		tgbotapi.NewMediaGroup(2, []any{
+			tgbotapi.NewInputMediaPhoto(tgbotapi.FileID("photo1")),
+			tgbotapi.NewInputMediaPhoto(tgbotapi.FileID("photo2")),
+		}),
+		tgbotapi.NewChatAction(2, tgbotapi.ChatTyping),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"sendMessage", "sendMediaGroup", "sendChatAction"}, fake.calledMethods())
+	assert.Equal(t, []int{11, 12, 13}, lo.Map(messages, func(message tgbotapi.Message, _ int) int {
+		return message.MessageID
+	}))
+}
+
+func TestProcessResponseOnSent(t *testing.T) {
+	nextMessageID := 100
+
+	ctx, _ := newTestContext(t, func(method string, _ *http.Request) (any, *tgbotapi.APIResponse) {
+		nextMessageID++
+
+		switch method {
+		case "copyMessage":
+			return map[string]any{"message_id": nextMessageID}, nil
+		default:
+			return sentMessageResult(nextMessageID), nil
+		}
+	})
+
+	sent := make([]int, 0)
+	onSent := func(c *Context, message *tgbotapi.Message) {
+		assert.Same(t, ctx, c)
+
+		sent = append(sent, message.MessageID)
+	}
+
+	processResponse(ctx, Responses{
+		ctx.NewMessage(strings.Repeat("a", MessageLengthLimit+1)).OnSent(onSent),
+		tgbotapi.NewCopyMessage(2, 2, 10),
+		ctx.NewPhoto(FileFromID("photo")).WithCaption(strings.Repeat("b", CaptionLengthLimit+1)).OnSent(onSent),
+	})
+
+	assert.Equal(t, []int{101, 102, 104, 105}, sent)
+	assert.Equal(t, []int{101, 102, 103, 104, 105}, lo.Map(ctx.LastSentMessages(), func(message tgbotapi.Message, _ int) int {
+		return message.MessageID
+	}))
+
+	processResponse(ctx, ctx.NewEditMessageText(101, "edited"))
+	assert.Empty(t, ctx.LastSentMessages())
+}
+
+func TestExecuteResponsesDisplacedWebhookReply(t *testing.T) {
+	ctx, fake := newTestContext(t, func(method string, _ *http.Request) (any, *tgbotapi.APIResponse) {
+		if method == "deleteMessage" {
+			return nil, &tgbotapi.APIResponse{Ok: false, ErrorCode: 400, Description: "Bad Request: message to delete not found"}
+		}
+
+		return sentMessageResult(11), nil
+	})
+	ctx.webhookReply = newWebhookReply()
+
+	messages, err := executeResponse(ctx, Responses{
+		ctx.NewDeleteMessage(10),
+		ctx.NewMessage("result"),
+	})
+	require.Error(t, err)
+	require.ErrorIs(t, err, ErrMessageToDeleteNotFound)
+
+	assert.Equal(t, []string{"deleteMessage", "sendMessage"}, fake.calledMethods())
+	require.Len(t, messages, 1)
+	assert.Equal(t, 11, messages[0].MessageID)
+}
@@ -0,0 +1,460 @@
+package tgo
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nekomeowww/tgo/pkg/tghtml"
+	"github.com/samber/lo"
+	"golang.org/x/text/width"
+)
+
+var (
+	matchMdFence          = regexp.MustCompile("^\\s*(```+|~~~+)\\s*([^`\\s]*)")
+	matchMdHeading        = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)(?:\s+#+)?\s*$`)
+	matchMdThematicBreak  = regexp.MustCompile(`^\s{0,3}((-\s*){3,}|(\*\s*){3,}|(_\s*){3,})$`)
+	matchMdBlockquote     = regexp.MustCompile(`^\s{0,3}>\s?`)
+	matchMdListItem       = regexp.MustCompile(`^(\s*)([-*+]|\d{1,9}[.)])\s+(.*)$`)
+	matchMdTaskListItem   = regexp.MustCompile(`^\[([ xX])\]\s+`)
+	matchMdTableDelimiter = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	matchMdAutolink       = regexp.MustCompile(`^<((?:https?|tg|mailto):[^<>\s]+)>`)
+)
+
+// MarkdownToTelegramHTML converts the Markdown, such as the replies of LLMs, to the
+// subset of HTML that Telegram supports, to be sent with the HTML parse mode.
+//
+// Bold, italic, strikethrough, spoilers, inline code, links, fenced code blocks and
+// blockquotes are converted to their tags, headings become bold, list items are
+// prefixed with bullets, the constructs without a counterpart degrade gracefully,
+// tables are rendered as aligned text in <pre>, and images become links. The rest
+// of the text is escaped with EscapeHTMLSymbols.
+//
+// https://core.telegram.org/bots/api#html-style
+func MarkdownToTelegramHTML(markdown string) string {
+	markdown = strings.ReplaceAll(markdown, "\r\n", "\n")
+
+	return convertMarkdownBlocks(strings.Split(markdown, "\n"), false)
+}
+
+func convertMarkdownBlocks(lines []string, inBlockquote bool) string {
+	blocks := make([]string, 0, len(lines))
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if fence := matchMdFence.FindStringSubmatch(line); fence != nil {
+			end := i + 1
+			for end < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[end]), fence[1]) {
+				end++
+			}
+
+			blocks = append(blocks, convertMarkdownCodeBlock(lines[i+1:min(end, len(lines))], fence[2]))
+			i = end
+
+			continue
+		}
+		if matchMdBlockquote.MatchString(line) {
+			end := i
+			quoted := make([]string, 0)
+
+			for end < len(lines) && matchMdBlockquote.MatchString(lines[end]) {
+				quoted = append(quoted, matchMdBlockquote.ReplaceAllString(lines[end], ""))
+				end++
+			}
+
+			// Telegram doesn't support nested blockquotes
+			if inBlockquote {
+				blocks = append(blocks, convertMarkdownBlocks(quoted, true))
+			} else {
+				blocks = append(blocks, "<blockquote>"+convertMarkdownBlocks(quoted, true)+"</blockquote>")
+			}
+
+			i = end - 1
+
+			continue
+		}
+		if i+1 < len(lines) && strings.Contains(line, "|") && matchMdTableDelimiter.MatchString(lines[i+1]) {
+			end := i + 2
+			for end < len(lines) && strings.Contains(lines[end], "|") && strings.TrimSpace(lines[end]) != "" {
+				end++
+			}
+
+			blocks = append(blocks, convertMarkdownTable(line, lines[i+1], lines[i+2:end]))
+			i = end - 1
+
+			continue
+		}
+		if heading := matchMdHeading.FindStringSubmatch(line); heading != nil {
+			blocks = append(blocks, "<b>"+convertMarkdownInline(heading[1])+"</b>")
+			continue
+		}
+		if matchMdThematicBreak.MatchString(line) {
+			blocks = append(blocks, "——————")
+			continue
+		}
+		if item := matchMdListItem.FindStringSubmatch(line); item != nil {
+			blocks = append(blocks, convertMarkdownListItem(item[1], item[2], item[3]))
+			continue
+		}
+
+		blocks = append(blocks, convertMarkdownInline(strings.TrimRightFunc(line, unicode.IsSpace)))
+	}
+
+	return strings.Join(blocks, "\n")
+}
+
+func convertMarkdownCodeBlock(lines []string, language string) string {
+	code := EscapeHTMLSymbols(strings.Join(lines, "\n"))
+	if language == "" {
+		return "<pre>" + code + "</pre>"
+	}
+
+	return `<pre><code class="language-` + tghtml.EscapeAttribute(language) + `">` + code + "</code></pre>"
+}
+
+func convertMarkdownListItem(indent string, marker string, content string) string {
+	level := utf8.RuneCountInString(strings.ReplaceAll(indent, "\t", "    ")) / 2
+
+	bullet := lo.Ternary(level == 0, "•", "◦")
+	if marker != "-" && marker != "*" && marker != "+" {
+		bullet = strings.TrimSuffix(strings.TrimSuffix(marker, ")"), ".") + "."
+	}
+	if task := matchMdTaskListItem.FindStringSubmatch(content); task != nil {
+		bullet = lo.Ternary(task[1] == " ", "☐", "☑")
+		content = content[len(task[0]):]
+	}
+
+	return strings.Repeat("  ", level) + bullet + " " + convertMarkdownInline(content)
+}
+
+// convertMarkdownTable renders the table as aligned plain text in <pre>, as
+// Telegram doesn't support tables.
+func convertMarkdownTable(header string, delimiter string, rows []string) string {
+	table := make([][]string, 0, len(rows)+1)
+
+	for _, row := range append([]string{header}, rows...) {
+		table = append(table, lo.Map(splitMarkdownTableRow(row), func(cell string, _ int) string {
+			return markdownInlineToPlainText(cell)
+		}))
+	}
+
+	alignments := splitMarkdownTableRow(delimiter)
+	columns := 0
+
+	for _, row := range table {
+		columns = max(columns, len(row))
+	}
+
+	widths := make([]int, columns)
+
+	for _, row := range table {
+		for i, cell := range row {
+			widths[i] = max(widths[i], displayWidth(cell))
+		}
+	}
+
+	lines := make([]string, 0, len(table)+1)
+
+	for i, row := range table {
+		cells := make([]string, columns)
+
+		for j := range cells {
+			cell := ""
+			if j < len(row) {
+				cell = row[j]
+			}
+
+			alignment := ""
+			if j < len(alignments) {
+				alignment = strings.TrimSpace(alignments[j])
+			}
+
+			cells[j] = padTableCell(cell, widths[j], alignment)
+		}
+
+		lines = append(lines, strings.TrimRightFunc(strings.Join(cells, " | "), unicode.IsSpace))
+
+		if i == 0 {
+			lines = append(lines, strings.Join(lo.Map(widths, func(w int, _ int) string {
+				return strings.Repeat("-", w)
+			}), "-+-"))
+		}
+	}
+
+	return "<pre>" + EscapeHTMLSymbols(strings.Join(lines, "\n")) + "</pre>"
+}
+
+func splitMarkdownTableRow(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+
+	if strings.HasSuffix(row, "|") && !strings.HasSuffix(row, `\|`) {
+		row = strings.TrimSuffix(row, "|")
+	}
+
+	cells := make([]string, 0)
+	cell := strings.Builder{}
+
+	for i := 0; i < len(row); i++ {
+		switch {
+		case row[i] == '\\' && i+1 < len(row) && row[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case row[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(row[i])
+		}
+	}
+
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+func padTableCell(cell string, w int, alignment string) string {
+	padding := w - displayWidth(cell)
+
+	switch {
+	case strings.HasPrefix(alignment, ":") && strings.HasSuffix(alignment, ":"):
+		return strings.Repeat(" ", padding/2) + cell + strings.Repeat(" ", padding-padding/2)
+	case strings.HasSuffix(alignment, ":"):
+		return strings.Repeat(" ", padding) + cell
+	default:
+		return cell + strings.Repeat(" ", padding)
+	}
+}
+
+// displayWidth returns the number of columns that the text occupies in monospace
+// fonts, the wide characters such as CJK occupy two.
+func displayWidth(text string) int {
+	w := 0
+
+	for _, r := range text {
+		kind := width.LookupRune(r).Kind()
+		if kind == width.EastAsianWide || kind == width.EastAsianFullwidth {
+			w += 2
+		} else {
+			w++
+		}
+	}
+
+	return w
+}
+
+func markdownInlineToPlainText(markdown string) string {
+	return html.UnescapeString(RemoveHTMLBlocksFromString(convertMarkdownInline(markdown)))
+}
+
+// markdownEscapableSymbols are the ASCII punctuations that can be escaped by a backslash.
+const markdownEscapableSymbols = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+// markdownInlineDelimiters are the delimiters of the inline formatting and the
+// tags they convert to, the longer ones are matched first.
+var markdownInlineDelimiters = []lo.Tuple3[string, string, string]{
+	lo.T3("***", "<b><i>", "</i></b>"),
+	lo.T3("**", "<b>", "</b>"),
+	lo.T3("__", "<b>", "</b>"),
+	lo.T3("~~", "<s>", "</s>"),
+	lo.T3("||", "<tg-spoiler>", "</tg-spoiler>"),
+	lo.T3("*", "<i>", "</i>"),
+	lo.T3("_", "<i>", "</i>"),
+}
+
+func convertMarkdownInline(markdown string) string {
+	var sb strings.Builder
+
+	var plain strings.Builder
+
+	flush := func() {
+		sb.WriteString(EscapeHTMLSymbols(plain.String()))
+		plain.Reset()
+	}
+
+	for i := 0; i < len(markdown); {
+		rest := markdown[i:]
+
+		if rest[0] == '\\' && len(rest) > 1 && strings.IndexByte(markdownEscapableSymbols, rest[1]) >= 0 {
+			plain.WriteByte(rest[1])
+			i += 2
+
+			continue
+		}
+		if rest[0] == '`' {
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			end := strings.Index(rest[ticks:], rest[:ticks])
+
+			if end >= 0 {
+				flush()
+				sb.WriteString("<code>" + EscapeHTMLSymbols(strings.TrimSpace(rest[ticks:ticks+end])) + "</code>")
+				i += ticks + end + ticks
+
+				continue
+			}
+
+			plain.WriteString(rest[:ticks])
+			i += ticks
+
+			continue
+		}
+		if rest[0] == '[' || strings.HasPrefix(rest, "![") {
+			text, url, n, ok := parseMarkdownLink(rest)
+			if ok {
+				flush()
+
+				isImage := rest[0] == '!'
+				if isImage && text == "" {
+					text = url
+				}
+
+				sb.WriteString(`<a href="` + tghtml.EscapeAttribute(url) + `">` + lo.Ternary(isImage, EscapeHTMLSymbols(text), convertMarkdownInline(text)) + "</a>")
+				i += n
+
+				continue
+			}
+		}
+		if autolink := matchMdAutolink.FindStringSubmatch(rest); autolink != nil {
+			flush()
+			sb.WriteString(`<a href="` + tghtml.EscapeAttribute(autolink[1]) + `">` + EscapeHTMLSymbols(autolink[1]) + "</a>")
+			i += len(autolink[0])
+
+			continue
+		}
+
+		content, delimiter, ok := matchMarkdownInlineDelimiter(markdown, i)
+		if ok {
+			flush()
+			sb.WriteString(delimiter.B + convertMarkdownInline(content) + delimiter.C)
+			i += len(delimiter.A)*2 + len(content)
+
+			continue
+		}
+
+		_, size := utf8.DecodeRuneInString(rest)
+		plain.WriteString(rest[:size])
+		i += size
+	}
+
+	flush()
+
+	return sb.String()
+}
+
+// matchMarkdownInlineDelimiter matches the formatting that starts at i, returns the
+// content between the delimiters.
+func matchMarkdownInlineDelimiter(markdown string, i int) (string, lo.Tuple3[string, string, string], bool) {
+	rest := markdown[i:]
+
+	for _, delimiter := range markdownInlineDelimiters {
+		if !strings.HasPrefix(rest, delimiter.A) {
+			continue
+		}
+
+		after := rest[len(delimiter.A):]
+		if after == "" || unicode.IsSpace(firstRune(after)) {
+			continue
+		}
+		// snake_case_words are not italic
+		if delimiter.A[0] == '_' && i > 0 && isWordRune(lastRune(markdown[:i])) {
+			continue
+		}
+
+		for from := 0; from < len(after); {
+			end := strings.Index(after[from:], delimiter.A)
+			if end < 0 {
+				break
+			}
+
+			end += from
+			from = end + 1
+
+			// the closing delimiter is a part of a longer one
+			if strings.HasPrefix(after[end+len(delimiter.A):], delimiter.A[:1]) && len(delimiter.A) < 3 {
+				continue
+			}
+			if end == 0 || unicode.IsSpace(lastRune(after[:end])) {
+				continue
+			}
+			if delimiter.A[0] == '_' && isWordRune(firstRune(after[end+len(delimiter.A):])) {
+				continue
+			}
+
+			return after[:end], delimiter, true
+		}
+	}
+
+	return "", lo.Tuple3[string, string, string]{}, false
+}
+
+// parseMarkdownLink parses [text](url "title") and ![alt](url) at the start of
+// the markdown, returns the text, the url and the length of the link.
+func parseMarkdownLink(markdown string) (string, string, int, bool) {
+	start := strings.IndexByte(markdown, '[') + 1
+	depth := 1
+	textEnd := -1
+
+	for i := start; i < len(markdown) && textEnd < 0; i++ {
+		switch markdown[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				textEnd = i
+			}
+		}
+	}
+	if textEnd < 0 || textEnd+1 >= len(markdown) || markdown[textEnd+1] != '(' {
+		return "", "", 0, false
+	}
+
+	depth = 1
+	urlEnd := -1
+
+	for i := textEnd + 2; i < len(markdown) && urlEnd < 0; i++ {
+		switch markdown[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				urlEnd = i
+			}
+		}
+	}
+	if urlEnd < 0 {
+		return "", "", 0, false
+	}
+
+	destination := strings.TrimSpace(markdown[textEnd+2 : urlEnd])
+	if strings.HasPrefix(destination, "<") {
+		destination = strings.TrimPrefix(strings.SplitN(destination, ">", 2)[0], "<")
+	} else if fields := strings.Fields(destination); len(fields) > 0 {
+		destination = fields[0]
+	}
+	if destination == "" {
+		return "", "", 0, false
+	}
+
+	return markdown[start:textEnd], destination, urlEnd + 1, true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func firstRune(str string) rune {
+	r, _ := utf8.DecodeRuneInString(str)
+	return r
+}
+
+func lastRune(str string) rune {
+	r, _ := utf8.DecodeLastRuneInString(str)
+	return r
+}
@@ -0,0 +1,71 @@
+package tgo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nekomeowww/tgo/pkg/tghtml"
+)
+
+func TestMarkdownToTelegramHTML(t *testing.T) {
+	testCases := []struct {
+		name     string
+		markdown string
+		expected string
+	}{
+		{name: "Plain", markdown: "hello & world", expected: "hello &amp; world"},
+		{name: "HTMLSymbols", markdown: "a <b> c", expected: "a &lt;b&gt; c"},
+		{name: "Bold", markdown: "**bold** and __bold__", expected: "<b>bold</b> and <b>bold</b>"},
+		{name: "Italic", markdown: "*italic* and _italic_", expected: "<i>italic</i> and <i>italic</i>"},
+		{name: "BoldItalic", markdown: "***both***", expected: "<b><i>both</i></b>"},
+		{name: "Nested", markdown: "**bold *italic* bold**", expected: "<b>bold <i>italic</i> bold</b>"},
+		{name: "SnakeCase", markdown: "snake_case_name", expected: "snake_case_name"},
+		{name: "Asterisks", markdown: "2 * 3 * 4", expected: "2 * 3 * 4"},
+		{name: "Strikethrough", markdown: "~~gone~~", expected: "<s>gone</s>"},
+		{name: "Spoiler", markdown: "||secret||", expected: "<tg-spoiler>secret</tg-spoiler>"},
+		{name: "Code", markdown: "run `go test` now", expected: "run <code>go test</code> now"},
+		{name: "CodeKeepsMarkers", markdown: "`**not bold**`", expected: "<code>**not bold**</code>"},
+		{name: "Escaped", markdown: `\*not italic\*`, expected: "*not italic*"},
+		{name: "Link", markdown: `[the **site**](https://example.com/?a=1&b=2 "title")`, expected: `<a href="https://example.com/?a=1&amp;b=2">the <b>site</b></a>`},
+		{name: "LinkWithParentheses", markdown: "[wiki](https://en.wikipedia.org/wiki/Go_(language))", expected: `<a href="https://en.wikipedia.org/wiki/Go_(language)">wiki</a>`},
+		{name: "Autolink", markdown: "<https://example.com>", expected: `<a href="https://example.com">https://example.com</a>`},
+		{name: "Image", markdown: "![a cat](https://example.com/cat.png)", expected: `<a href="https://example.com/cat.png">a cat</a>`},
+		{name: "ImageWithoutAlt", markdown: "![](https://example.com/cat.png)", expected: `<a href="https://example.com/cat.png">https://example.com/cat.png</a>`},
+		{name: "Heading", markdown: "## Title in C#", expected: "<b>Title in C#</b>"},
+		{name: "ThematicBreak", markdown: "a\n\n---\n\nb", expected: "a\n\n——————\n\nb"},
+		{
+			name:     "UnorderedList",
+			markdown: "- one\n- **two**\n  - nested\n* [ ] todo\n* [x] done",
+			expected: "• one\n• <b>two</b>\n  ◦ nested\n☐ todo\n☑ done",
+		},
+		{name: "OrderedList", markdown: "1. one\n2) two", expected: "1. one\n2. two"},
+		{
+			name:     "CodeBlock",
+			markdown: "```go\nfmt.Println(\"*\" & 1)\n```\nafter",
+			expected: "<pre><code class=\"language-go\">fmt.Println(\"*\" &amp; 1)</code></pre>\nafter",
+		},
+		{name: "CodeBlockQuotedLanguage", markdown: "```c\"\nx\n```", expected: `<pre><code class="language-c&quot;">x</code></pre>`},
+		{name: "CodeBlockWithoutLanguage", markdown: "~~~\n**x**\n~~~", expected: "<pre>**x**</pre>"},
+		{name: "UnterminatedCodeBlock", markdown: "```\ncode", expected: "<pre>code</pre>"},
+		{
+			name:     "Blockquote",
+			markdown: "> quoted **text**\n> > nested\nafter",
+			expected: "<blockquote>quoted <b>text</b>\nnested</blockquote>\nafter",
+		},
+		{
+			name:     "Table",
+			markdown: "| Name | Score |\n|:-----|------:|\n| **Bob** | 3 |\n| 小明 | 100 |",
+			expected: "<pre>Name | Score\n-----+------\nBob  |     3\n小明 |   100</pre>",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			html := MarkdownToTelegramHTML(tc.markdown)
+
+			assert.Equal(t, tc.expected, html)
+			assert.NoError(t, tghtml.Validate(html))
+		})
+	}
+}
@@ -1,30 +1,51 @@
 package tgo
 
 import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf16"
 	"unicode/utf8"
 
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/samber/lo"
 )
 
+// DefaultMessageGroupSeparator is the separator that the items of a message
+// group are assumed to be joined with by SplitMessagesAgainstLengthLimitIntoMessageGroups.
+const DefaultMessageGroupSeparator = "\n\n"
+
+// SplitMessagesAgainstLengthLimitIntoMessageGroups groups the plain texts so that
+// each group joined with DefaultMessageGroupSeparator fits in MessageLengthLimit.
 func SplitMessagesAgainstLengthLimitIntoMessageGroups(originalSlice []string) [][]string {
-	count := 0
-	tempSlice := make([]string, 0)
+	return SplitMessagesAgainstLengthLimitIntoMessageGroupsWithParseMode(originalSlice, DefaultMessageGroupSeparator, "")
+}
+
+// SplitMessagesAgainstLengthLimitIntoMessageGroupsWithParseMode groups the texts
+// formatted with the parse mode so that each group joined with the separator fits
+// in MessageLengthLimit. The text longer than the limit makes a group on its own.
+func SplitMessagesAgainstLengthLimitIntoMessageGroupsWithParseMode(originalSlice []string, separator string, parseMode string) [][]string {
+	separatorLength := MessageTextLength(separator, parseMode)
+
 	batchSlice := make([][]string, 0)
+	tempSlice := make([]string, 0)
+	count := 0
 
 	for _, s := range originalSlice {
-		tempSlice = append(tempSlice, s)
-		count += utf8.RuneCountInString(s) + 20
+		length := MessageTextLength(s, parseMode)
 
-		if count >= 4096 {
-			tempSlice = lo.DropRight(tempSlice, 1)     // rollback the last append
+		if len(tempSlice) > 0 && count+separatorLength+length > MessageLengthLimit {
 			batchSlice = append(batchSlice, tempSlice) // commit the batch
 
 			tempSlice = make([]string, 0) // reset the temp slice
 			count = 0                     // reset the count
-
-			tempSlice = append(tempSlice, s)        // re-append the last element
-			count += utf8.RuneCountInString(s) + 20 // re-calculating the count
 		}
+		if len(tempSlice) > 0 {
+			count += separatorLength
+		}
+
+		tempSlice = append(tempSlice, s)
+		count += length
 	}
 
 	// if there are still elements in the temp slice, append them to the batch
@@ -34,3 +55,396 @@ func SplitMessagesAgainstLengthLimitIntoMessageGroups(originalSlice []string) []
 
 	return batchSlice
 }
+
+// UTF16Length returns the length of the text in UTF-16 code units, which is how
+// Telegram counts the length of texts, an emoji usually counts as 2.
+func UTF16Length(text string) int {
+	length := 0
+
+	for _, r := range text {
+		length += utf16RuneLen(r)
+	}
+
+	return length
+}
+
+func utf16RuneLen(r rune) int {
+	n := utf16.RuneLen(r)
+	if n < 0 {
+		return 1
+	}
+
+	return n
+}
+
+// MessageTextLength returns the length of the text that Telegram counts against
+// MessageLengthLimit and CaptionLengthLimit, that is the UTF-16 length of the text
+// rendered with the parse mode, the tags of HTML and the markers and escapes of
+// MarkdownV2 are not counted.
+func MessageTextLength(text string, parseMode string) int {
+	length := 0
+
+	for _, token := range tokenizeMessageText(text, parseMode) {
+		length += token.length
+	}
+
+	return length
+}
+
+// messageToken is the smallest piece of a message text that can't be split, a
+// rune, an HTML tag or entity, or an escape or formatting marker of MarkdownV2.
+type messageToken struct {
+	text string
+	// length is the UTF-16 length of the token when rendered.
+	length int
+	// tag is the lower cased name of the HTML tag or the marker of MarkdownV2 that
+	// the token opens or closes, empty for the others.
+	tag     string
+	closing bool
+	// close is the text that closes the formatting opened by the token.
+	close string
+}
+
+func newTextToken(text string) messageToken {
+	return messageToken{text: text, length: UTF16Length(text)}
+}
+
+func tokenizeMessageText(text string, parseMode string) []messageToken {
+	switch parseMode {
+	case tgbotapi.ModeHTML:
+		return tokenizeHTML(text)
+	case tgbotapi.ModeMarkdownV2:
+		return tokenizeMarkdownV2(text)
+	default:
+		return tokenizeRunes(text)
+	}
+}
+
+func tokenizeRunes(text string) []messageToken {
+	tokens := make([]messageToken, 0, len(text))
+
+	for len(text) > 0 {
+		_, size := utf8.DecodeRuneInString(text)
+		tokens = append(tokens, newTextToken(text[:size]))
+		text = text[size:]
+	}
+
+	return tokens
+}
+
+var (
+	matchHTMLEntity = regexp.MustCompile(`^&(#[0-9]+|#[xX][0-9a-fA-F]+|[a-zA-Z]+);`)
+	// the > in quoted attribute values doesn't end the tag
+	matchHTMLTag = regexp.MustCompile(`^<(?:"[^"]*"|'[^']*'|[^"'>])*>`)
+)
+
+func tokenizeHTML(text string) []messageToken {
+	tokens := make([]messageToken, 0, len(text))
+
+	for len(text) > 0 {
+		if text[0] == '<' {
+			tag := matchHTMLTag.FindString(text)
+			if tag != "" {
+				tokens = append(tokens, newHTMLTagToken(tag))
+				text = text[len(tag):]
+
+				continue
+			}
+		}
+		if text[0] == '&' {
+			entity := matchHTMLEntity.FindString(text)
+			if entity != "" {
+				tokens = append(tokens, messageToken{text: entity, length: UTF16Length(html.UnescapeString(entity))})
+				text = text[len(entity):]
+
+				continue
+			}
+		}
+
+		_, size := utf8.DecodeRuneInString(text)
+		tokens = append(tokens, newTextToken(text[:size]))
+		text = text[size:]
+	}
+
+	return tokens
+}
+
+func newHTMLTagToken(tag string) messageToken {
+	token := messageToken{text: tag}
+
+	name := strings.TrimPrefix(tag[1:len(tag)-1], "/")
+	token.closing = strings.HasPrefix(tag, "</")
+
+	end := strings.IndexFunc(name, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == '/'
+	})
+	if end >= 0 {
+		name = name[:end]
+	}
+
+	token.tag = strings.ToLower(name)
+	token.close = "</" + token.tag + ">"
+
+	return token
+}
+
+var matchMarkdownV2Link = regexp.MustCompile(`^!?\[(?:\\.|[^\]\\])*\]\(((?:\\.|[^)\\])*)\)`)
+
+// tokenizeMarkdownV2 tokenizes the text formatted with MarkdownV2.
+//
+// https://core.telegram.org/bots/api#markdownv2-style
+func tokenizeMarkdownV2(text string) []messageToken {
+	tokens := make([]messageToken, 0, len(text))
+	toggled := make(map[string]bool)
+	links := make([]string, 0)
+	code := ""
+	lineStart := true
+
+	marker := func(m string, closing bool, close string) {
+		tokens = append(tokens, messageToken{text: m, tag: m, closing: closing, close: close})
+		text = text[len(m):]
+	}
+
+	for len(text) > 0 {
+		tokensCount := len(tokens)
+
+		switch {
+		case text[0] == '\\' && len(text) > 1:
+			_, size := utf8.DecodeRuneInString(text[1:])
+			tokens = append(tokens, messageToken{text: text[:1+size], length: UTF16Length(text[1 : 1+size])})
+			text = text[1+size:]
+		case code != "":
+			// only ` and \ are escaped in code and pre
+			if strings.HasPrefix(text, code) {
+				tokens = append(tokens, messageToken{text: code, tag: code, closing: true})
+				text = text[len(code):]
+				code = ""
+
+				break
+			}
+
+			_, size := utf8.DecodeRuneInString(text)
+			tokens = append(tokens, newTextToken(text[:size]))
+			text = text[size:]
+		case strings.HasPrefix(text, "```"):
+			opening := "```"
+			// the language of the pre
+			end := strings.IndexByte(text, '\n')
+			if end >= 0 && !strings.Contains(text[3:end], "`") {
+				opening = text[:end+1]
+			}
+
+			tokens = append(tokens, messageToken{text: opening, tag: "```", close: "```"})
+			text = text[len(opening):]
+			code = "```"
+		case text[0] == '`':
+			marker("`", false, "`")
+			code = "`"
+		case lineStart && strings.HasPrefix(text, "**>"):
+			tokens = append(tokens, messageToken{text: "**>"})
+			text = text[3:]
+		case lineStart && text[0] == '>':
+			tokens = append(tokens, messageToken{text: ">"})
+			text = text[1:]
+		case text[0] == '[' || strings.HasPrefix(text, "!["):
+			match := matchMarkdownV2Link.FindStringSubmatch(text)
+			if match == nil {
+				tokens = append(tokens, newTextToken(text[:1]))
+				text = text[1:]
+
+				break
+			}
+
+			close := "](" + match[1] + ")"
+			links = append(links, close)
+			marker(lo.Ternary(text[0] == '!', "![", "["), false, close)
+		case text[0] == ']' && len(links) > 0 && strings.HasPrefix(text, links[len(links)-1]):
+			close := links[len(links)-1]
+			links = links[:len(links)-1]
+
+			tokens = append(tokens, messageToken{text: close, tag: "[", closing: true})
+			text = text[len(close):]
+		default:
+			m, ok := lo.Find([]string{"__", "||", "*", "_", "~"}, func(m string) bool {
+				return strings.HasPrefix(text, m)
+			})
+			if ok {
+				marker(m, toggled[m], m)
+				toggled[m] = !toggled[m]
+
+				break
+			}
+
+			_, size := utf8.DecodeRuneInString(text)
+			tokens = append(tokens, newTextToken(text[:size]))
+			text = text[size:]
+		}
+
+		lineStart = strings.HasSuffix(tokens[tokensCount].text, "\n")
+	}
+
+	return tokens
+}
+
+// SplitMessageText splits the text into parts that fit in MessageLengthLimit, at
+// line breaks or spaces whenever possible. With the HTML and MarkdownV2 parse
+// modes, the formatting open at the end of a part is closed and opened again at
+// the start of the next part.
+func SplitMessageText(text string, parseMode string) []string {
+	return splitMessageText(text, parseMode, MessageLengthLimit)
+}
+
+func splitMessageText(text string, parseMode string, limit int) []string {
+	tokens := tokenizeMessageText(text, parseMode)
+	parts := make([]string, 0, 1)
+
+	var reopened []messageToken
+
+	for len(tokens) > 0 {
+		cut := messageTokensCut(tokens, limit)
+
+		part := append(append(make([]messageToken, 0, len(reopened)+cut), reopened...), tokens[:cut]...)
+		open := openFormattingTokens(part)
+
+		var sb strings.Builder
+
+		for _, token := range part {
+			sb.WriteString(token.text)
+		}
+		for i := len(open) - 1; i >= 0; i-- {
+			sb.WriteString(open[i].close)
+		}
+
+		parts = append(parts, sb.String())
+		reopened = open
+		tokens = tokens[cut:]
+	}
+
+	return parts
+}
+
+// splitMessageTextWithEntities splits the plain text formatted by the entities like
+// splitMessageText, the entities are clipped to the parts they fall in, with the
+// offsets relative to the parts.
+func splitMessageTextWithEntities(text string, entities []tgbotapi.MessageEntity, limit int) ([]string, [][]tgbotapi.MessageEntity) {
+	tokens := tokenizeRunes(text)
+	parts := make([]string, 0, 1)
+	partsEntities := make([][]tgbotapi.MessageEntity, 0, 1)
+	start := 0
+
+	for len(tokens) > 0 {
+		cut := messageTokensCut(tokens, limit)
+
+		var sb strings.Builder
+
+		end := start
+
+		for _, token := range tokens[:cut] {
+			sb.WriteString(token.text)
+			end += token.length
+		}
+
+		partEntities := make([]tgbotapi.MessageEntity, 0)
+
+		for _, entity := range entities {
+			entityStart := max(entity.Offset, start)
+			entityEnd := min(entity.Offset+entity.Length, end)
+
+			if entityEnd <= entityStart {
+				continue
+			}
+
+			entity.Offset = entityStart - start
+			entity.Length = entityEnd - entityStart
+			partEntities = append(partEntities, entity)
+		}
+
+		parts = append(parts, sb.String())
+		partsEntities = append(partsEntities, partEntities)
+		tokens = tokens[cut:]
+		start = end
+	}
+
+	return parts, partsEntities
+}
+
+// messageTokensCut returns how many of the tokens go into the next part.
+func messageTokensCut(tokens []messageToken, limit int) int {
+	length := 0
+	end := len(tokens)
+	lastNewLine := -1
+	lastSpace := -1
+
+	for i, token := range tokens {
+		if length+token.length > limit {
+			end = i
+			break
+		}
+
+		length += token.length
+
+		switch token.text {
+		case "\n":
+			lastNewLine = i + 1
+		case " ":
+			lastSpace = i + 1
+		}
+	}
+	if end == len(tokens) {
+		return end
+	}
+	// the rest are tags or spaces that make an empty message on their own
+	if strings.TrimSpace(messageTokensVisibleText(tokens[end:])) == "" {
+		return len(tokens)
+	}
+
+	switch {
+	case lastNewLine > end/2:
+		return lastNewLine
+	case lastSpace > 0:
+		return lastSpace
+	case lastNewLine > 0:
+		return lastNewLine
+	case end > 0:
+		return end
+	default:
+		return 1
+	}
+}
+
+func messageTokensVisibleText(tokens []messageToken) string {
+	var sb strings.Builder
+
+	for _, token := range tokens {
+		if token.length > 0 {
+			sb.WriteString(token.text)
+		}
+	}
+
+	return sb.String()
+}
+
+// openFormattingTokens returns the tokens that open the formatting which is not
+// closed at the end of the tokens.
+func openFormattingTokens(tokens []messageToken) []messageToken {
+	open := make([]messageToken, 0)
+
+	for _, token := range tokens {
+		if token.tag == "" {
+			continue
+		}
+		if !token.closing {
+			open = append(open, token)
+			continue
+		}
+
+		_, index, ok := lo.FindLastIndexOf(open, func(item messageToken) bool {
+			return item.tag == token.tag
+		})
+		if ok {
+			open = open[:index]
+		}
+	}
+
+	return open
+}
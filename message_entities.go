@@ -0,0 +1,386 @@
+package tgo
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/samber/lo"
+
+	"github.com/nekomeowww/tgo/pkg/tghtml"
+)
+
+// MessageToHTML returns the text or the caption of the message formatted by its
+// entities as Telegram HTML, to quote or forward the content with the formatting.
+func MessageToHTML(message *tgbotapi.Message) string {
+	text, entities := messageTextAndEntities(message)
+	return EntitiesToHTML(text, entities)
+}
+
+// MessageToMarkdownV2 returns the text or the caption of the message formatted by
+// its entities as Telegram MarkdownV2.
+func MessageToMarkdownV2(message *tgbotapi.Message) string {
+	text, entities := messageTextAndEntities(message)
+	return EntitiesToMarkdownV2(text, entities)
+}
+
+// MessageToMarkdown returns the text or the caption of the message formatted by
+// its entities as CommonMark, with strikethrough of GitHub Flavored Markdown. The
+// underlines and spoilers are not supported by CommonMark and left as plain text.
+func MessageToMarkdown(message *tgbotapi.Message) string {
+	text, entities := messageTextAndEntities(message)
+	return EntitiesToMarkdown(text, entities)
+}
+
+func messageTextAndEntities(message *tgbotapi.Message) (string, []tgbotapi.MessageEntity) {
+	if message == nil {
+		return "", nil
+	}
+	if message.Text != "" {
+		return message.Text, message.Entities
+	}
+
+	return message.Caption, message.CaptionEntities
+}
+
+// EntitiesToHTML formats the text by the entities as Telegram HTML.
+func EntitiesToHTML(text string, entities []tgbotapi.MessageEntity) string {
+	return renderMessageEntities(text, entities, htmlEntityFormat)
+}
+
+// EntitiesToMarkdownV2 formats the text by the entities as Telegram MarkdownV2.
+func EntitiesToMarkdownV2(text string, entities []tgbotapi.MessageEntity) string {
+	return renderMessageEntities(text, entities, markdownV2EntityFormat)
+}
+
+// EntitiesToMarkdown formats the text by the entities as CommonMark.
+func EntitiesToMarkdown(text string, entities []tgbotapi.MessageEntity) string {
+	return renderMessageEntities(text, entities, markdownEntityFormat)
+}
+
+// messageEntityFormat renders the entities in a markup language, open and close
+// return false for the entities that the language doesn't support.
+type messageEntityFormat struct {
+	open   func(entity tgbotapi.MessageEntity) (string, bool)
+	close  func(entity tgbotapi.MessageEntity) string
+	escape func(text string, active []tgbotapi.MessageEntity) string
+}
+
+func isCodeEntity(entity tgbotapi.MessageEntity) bool {
+	return entity.Type == string(MessageEntityTypeCode) || entity.Type == string(MessageEntityTypePre)
+}
+
+func hasEntityOfType(entities []tgbotapi.MessageEntity, entityType MessageEntityType) bool {
+	return lo.ContainsBy(entities, func(entity tgbotapi.MessageEntity) bool {
+		return entity.Type == string(entityType)
+	})
+}
+
+func textMentionURL(user *tgbotapi.User) string {
+	return "tg://user?id=" + strconv.FormatInt(user.ID, 10)
+}
+
+var htmlEntityFormat = messageEntityFormat{
+	open: func(entity tgbotapi.MessageEntity) (string, bool) {
+		switch MessageEntityType(entity.Type) {
+		case MessageEntityTypeBold:
+			return "<b>", true
+		case MessageEntityTypeItalic:
+			return "<i>", true
+		case MessageEntityTypeUnderline:
+			return "<u>", true
+		case MessageEntityTypeStrikethrough:
+			return "<s>", true
+		case MessageEntityTypeSpoiler:
+			return "<tg-spoiler>", true
+		case MessageEntityTypeBlockquote:
+			return "<blockquote>", true
+		case MessageEntityTypeCode:
+			return "<code>", true
+		case MessageEntityTypePre:
+			if entity.Language != "" {
+				return `<pre><code class="language-` + tghtml.EscapeAttribute(entity.Language) + `">`, true
+			}
+
+			return "<pre>", true
+		case MessageEntityTypeTextLink:
+			return `<a href="` + tghtml.EscapeAttribute(entity.URL) + `">`, true
+		case MessageEntityTypeTextMention:
+			if entity.User == nil {
+				return "", false
+			}
+
+			return `<a href="` + textMentionURL(entity.User) + `">`, true
+		default:
+			return "", false
+		}
+	},
+	close: func(entity tgbotapi.MessageEntity) string {
+		switch MessageEntityType(entity.Type) {
+		case MessageEntityTypeBold:
+			return "</b>"
+		case MessageEntityTypeItalic:
+			return "</i>"
+		case MessageEntityTypeUnderline:
+			return "</u>"
+		case MessageEntityTypeStrikethrough:
+			return "</s>"
+		case MessageEntityTypeSpoiler:
+			return "</tg-spoiler>"
+		case MessageEntityTypeBlockquote:
+			return "</blockquote>"
+		case MessageEntityTypeCode:
+			return "</code>"
+		case MessageEntityTypePre:
+			return lo.Ternary(entity.Language != "", "</code></pre>", "</pre>")
+		case MessageEntityTypeTextLink, MessageEntityTypeTextMention:
+			return "</a>"
+		default:
+			return ""
+		}
+	},
+	escape: func(text string, _ []tgbotapi.MessageEntity) string {
+		return tghtml.Escape(text)
+	},
+}
+
+var (
+	markdownV2Escaper     = strings.NewReplacer(lo.FlatMap([]string{"\\", "_", "*", "[", "]", "(", ")", "~", "`", ">", "#", "+", "-", "=", "|", "{", "}", ".", "!"}, func(s string, _ int) []string { return []string{s, "\\" + s} })...)
+	markdownV2CodeEscaper = strings.NewReplacer("\\", "\\\\", "`", "\\`")
+	markdownV2URLEscaper  = strings.NewReplacer("\\", "\\\\", ")", "\\)")
+)
+
+var markdownV2EntityFormat = messageEntityFormat{
+	open: func(entity tgbotapi.MessageEntity) (string, bool) {
+		switch MessageEntityType(entity.Type) {
+		case MessageEntityTypeBold:
+			return "*", true
+		case MessageEntityTypeItalic:
+			return "_", true
+		case MessageEntityTypeUnderline:
+			return "__", true
+		case MessageEntityTypeStrikethrough:
+			return "~", true
+		case MessageEntityTypeSpoiler:
+			return "||", true
+		case MessageEntityTypeBlockquote:
+			return ">", true
+		case MessageEntityTypeCode:
+			return "`", true
+		case MessageEntityTypePre:
+			return "```" + entity.Language + "\n", true
+		case MessageEntityTypeTextLink, MessageEntityTypeTextMention:
+			if entity.Type == string(MessageEntityTypeTextMention) && entity.User == nil {
+				return "", false
+			}
+
+			return "[", true
+		default:
+			return "", false
+		}
+	},
+	close: func(entity tgbotapi.MessageEntity) string {
+		switch MessageEntityType(entity.Type) {
+		case MessageEntityTypeBold:
+			return "*"
+		case MessageEntityTypeItalic:
+			// \r is ignored by Telegram, it separates the italic from the underline
+			// closed right after, which is parsed greedily otherwise
+			return "_\r"
+		case MessageEntityTypeUnderline:
+			return "__"
+		case MessageEntityTypeStrikethrough:
+			return "~"
+		case MessageEntityTypeSpoiler:
+			return "||"
+		case MessageEntityTypeBlockquote:
+			return ""
+		case MessageEntityTypeCode:
+			return "`"
+		case MessageEntityTypePre:
+			return "\n```"
+		case MessageEntityTypeTextLink:
+			return "](" + markdownV2URLEscaper.Replace(entity.URL) + ")"
+		case MessageEntityTypeTextMention:
+			return "](" + textMentionURL(entity.User) + ")"
+		default:
+			return ""
+		}
+	},
+	escape: func(text string, active []tgbotapi.MessageEntity) string {
+		if lo.ContainsBy(active, isCodeEntity) {
+			return markdownV2CodeEscaper.Replace(text)
+		}
+
+		text = markdownV2Escaper.Replace(text)
+		if hasEntityOfType(active, MessageEntityTypeBlockquote) {
+			text = strings.ReplaceAll(text, "\n", "\n>")
+		}
+
+		return text
+	},
+}
+
+var (
+	markdownEscaper = strings.NewReplacer(lo.FlatMap([]string{"\\", "`", "*", "_", "{", "}", "[", "]", "<", ">", "(", ")", "#", "+", "-", ".", "!", "|", "~"}, func(s string, _ int) []string { return []string{s, "\\" + s} })...)
+	// markdownURLEscaper escapes the URL as a CommonMark link destination in <>,
+	// which can't contain line breaks nor unescaped < and >.
+	markdownURLEscaper = strings.NewReplacer("\\", "\\\\", "<", "\\<", ">", "\\>", "\n", "%0A", "\r", "%0D")
+)
+
+var markdownEntityFormat = messageEntityFormat{
+	open: func(entity tgbotapi.MessageEntity) (string, bool) {
+		switch MessageEntityType(entity.Type) {
+		case MessageEntityTypeBold:
+			return "**", true
+		case MessageEntityTypeItalic:
+			return "*", true
+		case MessageEntityTypeStrikethrough:
+			return "~~", true
+		case MessageEntityTypeBlockquote:
+			return "> ", true
+		case MessageEntityTypeCode:
+			return "`", true
+		case MessageEntityTypePre:
+			return "```" + entity.Language + "\n", true
+		case MessageEntityTypeTextLink, MessageEntityTypeTextMention:
+			if entity.Type == string(MessageEntityTypeTextMention) && entity.User == nil {
+				return "", false
+			}
+
+			return "[", true
+		default:
+			return "", false
+		}
+	},
+	close: func(entity tgbotapi.MessageEntity) string {
+		switch MessageEntityType(entity.Type) {
+		case MessageEntityTypeBold:
+			return "**"
+		case MessageEntityTypeItalic:
+			return "*"
+		case MessageEntityTypeStrikethrough:
+			return "~~"
+		case MessageEntityTypeCode:
+			return "`"
+		case MessageEntityTypePre:
+			return "\n```"
+		case MessageEntityTypeTextLink:
+			return "](<" + markdownURLEscaper.Replace(entity.URL) + ">)"
+		case MessageEntityTypeTextMention:
+			return "](" + textMentionURL(entity.User) + ")"
+		default:
+			return ""
+		}
+	},
+	escape: func(text string, active []tgbotapi.MessageEntity) string {
+		if lo.ContainsBy(active, isCodeEntity) {
+			return text
+		}
+
+		text = markdownEscaper.Replace(text)
+		if hasEntityOfType(active, MessageEntityTypeBlockquote) {
+			text = strings.ReplaceAll(text, "\n", "\n> ")
+		}
+
+		return text
+	},
+}
+
+// renderMessageEntities formats the text by the entities. The entities that
+// overlap without nesting are closed and opened again around the boundaries, so
+// that the markup is always well nested. The entities inside code and pre are
+// dropped as Telegram doesn't allow them.
+func renderMessageEntities(text string, entities []tgbotapi.MessageEntity, format messageEntityFormat) string {
+	units := utf16.Encode([]rune(text))
+	entities = renderableMessageEntities(entities, len(units), format)
+
+	boundaries := []int{0, len(units)}
+
+	for _, entity := range entities {
+		boundaries = append(boundaries, entity.Offset, entity.Offset+entity.Length)
+	}
+
+	boundaries = lo.Uniq(boundaries)
+	sort.Ints(boundaries)
+
+	var sb strings.Builder
+
+	stack := make([]tgbotapi.MessageEntity, 0)
+
+	for i, position := range boundaries {
+		// close the entities ending here, along with the ones opened after them
+		_, index, ending := lo.FindIndexOf(stack, func(entity tgbotapi.MessageEntity) bool {
+			return entity.Offset+entity.Length == position
+		})
+
+		reopening := make([]tgbotapi.MessageEntity, 0)
+
+		if ending {
+			for j := len(stack) - 1; j >= index; j-- {
+				sb.WriteString(format.close(stack[j]))
+
+				if stack[j].Offset+stack[j].Length > position {
+					reopening = append([]tgbotapi.MessageEntity{stack[j]}, reopening...)
+				}
+			}
+
+			stack = stack[:index]
+		}
+
+		starting := lo.Filter(entities, func(entity tgbotapi.MessageEntity, _ int) bool {
+			return entity.Offset == position
+		})
+
+		for _, entity := range append(reopening, starting...) {
+			opening, _ := format.open(entity)
+
+			sb.WriteString(opening)
+			stack = append(stack, entity)
+		}
+
+		if i+1 < len(boundaries) {
+			sb.WriteString(format.escape(string(utf16.Decode(units[position:boundaries[i+1]])), stack))
+		}
+	}
+
+	return sb.String()
+}
+
+// renderableMessageEntities returns the entities that the format supports, clipped
+// to the text, sorted by the offsets, the outer ones first.
+func renderableMessageEntities(entities []tgbotapi.MessageEntity, length int, format messageEntityFormat) []tgbotapi.MessageEntity {
+	renderable := make([]tgbotapi.MessageEntity, 0, len(entities))
+
+	for _, entity := range entities {
+		if _, ok := format.open(entity); !ok {
+			continue
+		}
+
+		end := min(entity.Offset+entity.Length, length)
+		entity.Offset = max(entity.Offset, 0)
+		entity.Length = end - entity.Offset
+
+		if entity.Length <= 0 {
+			continue
+		}
+
+		renderable = append(renderable, entity)
+	}
+
+	sort.SliceStable(renderable, func(i, j int) bool {
+		if renderable[i].Offset != renderable[j].Offset {
+			return renderable[i].Offset < renderable[j].Offset
+		}
+
+		return renderable[i].Length > renderable[j].Length
+	})
+
+	return lo.Reject(renderable, func(entity tgbotapi.MessageEntity, i int) bool {
+		return lo.ContainsBy(renderable[:i], func(outer tgbotapi.MessageEntity) bool {
+			return isCodeEntity(outer) && entity.Offset < outer.Offset+outer.Length
+		})
+	})
+}
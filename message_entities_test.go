@@ -0,0 +1,129 @@
+package tgo
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntitiesToHTML(t *testing.T) {
+	t.Run("Nested", func(t *testing.T) {
+		text := Text().
+			Plain("👋 ").
+			Styled(tgbotapi.MessageEntity{Type: string(MessageEntityTypeBold)}, func(b *TextBuilder) {
+				b.Plain("a<b ").Italic("c&d")
+			}).
+			Plain(" ").
+			Link("link", `https://example.com/?a="b"&c`).
+			Plain(" ").
+			Mention(&tgbotapi.User{ID: 1, FirstName: "Neko"}).
+			Plain(" ").
+			Pre("x < y", "go")
+
+		assert.Equal(t,
+			`👋 <b>a&lt;b <i>c&amp;d</i></b> <a href="https://example.com/?a=&quot;b&quot;&amp;c">link</a> <a href="tg://user?id=1">Neko</a> <pre><code class="language-go">x &lt; y</code></pre>`,
+			EntitiesToHTML(text.String(), text.Entities()),
+		)
+	})
+
+	t.Run("Overlapping", func(t *testing.T) {
+		entities := []tgbotapi.MessageEntity{
+			{Type: "bold", Offset: 0, Length: 6},
+			{Type: "italic", Offset: 3, Length: 6},
+		}
+
+		assert.Equal(t, "<b>abc<i>def</i></b><i>ghi</i>", EntitiesToHTML("abcdefghi", entities))
+	})
+
+	t.Run("SameRange", func(t *testing.T) {
+		entities := []tgbotapi.MessageEntity{
+			{Type: "bold", Offset: 0, Length: 3},
+			{Type: "underline", Offset: 0, Length: 3},
+		}
+
+		assert.Equal(t, "<b><u>abc</u></b>", EntitiesToHTML("abc", entities))
+	})
+
+	t.Run("InsideCode", func(t *testing.T) {
+		entities := []tgbotapi.MessageEntity{
+			{Type: "code", Offset: 0, Length: 5},
+			{Type: "bold", Offset: 2, Length: 6},
+		}
+
+		assert.Equal(t, "<code>abcde</code>fgh", EntitiesToHTML("abcdefgh", entities))
+	})
+
+	t.Run("UnsupportedAndOutOfRange", func(t *testing.T) {
+		entities := []tgbotapi.MessageEntity{
+			{Type: "mention", Offset: 0, Length: 5},
+			{Type: "bold", Offset: 6, Length: 10},
+			{Type: "italic", Offset: 20, Length: 1},
+		}
+
+		assert.Equal(t, "@neko <b>meow</b>", EntitiesToHTML("@neko meow", entities))
+	})
+}
+
+func TestEntitiesToMarkdownV2(t *testing.T) {
+	text := Text().
+		Bold("a.b").
+		Plain(" ").
+		Italic("c").
+		Plain(" ").
+		Link("link", "https://example.com/(x)").
+		Plain(" ").
+		Code("`x`").
+		Plain("\n").
+		Blockquote("line 1\nline-2")
+
+	assert.Equal(t,
+		"*a\\.b* _c_\r [link](https://example.com/(x\\)) `\\`x\\``\n>line 1\n>line\\-2",
+		EntitiesToMarkdownV2(text.String(), text.Entities()),
+	)
+
+	entities := []tgbotapi.MessageEntity{
+		{Type: "underline", Offset: 0, Length: 3},
+		{Type: "italic", Offset: 0, Length: 3},
+	}
+
+	assert.Equal(t, "___abc_\r__", EntitiesToMarkdownV2("abc", entities))
+}
+
+func TestEntitiesToMarkdown(t *testing.T) {
+	text := Text().
+		Bold("a*b").
+		Plain(" ").
+		Italic("c").
+		Plain(" ").
+		Underline("u").
+		Spoiler("s").
+		Strikethrough("d").
+		Plain(" ").
+		Link("link", "https://example.com/(x)").
+		Plain("\n").
+		Pre("fmt.Println(\"*\")", "go").
+		Plain("\n").
+		Blockquote("line 1\nline 2")
+
+	assert.Equal(t,
+		"**a\\*b** *c* us~~d~~ [link](<https://example.com/(x)>)\n```go\nfmt.Println(\"*\")\n```\n> line 1\n> line 2",
+		EntitiesToMarkdown(text.String(), text.Entities()),
+	)
+
+	link := Text().Link("link", "https://example.com/?a=<b>\nc")
+
+	assert.Equal(t, "[link](<https://example.com/?a=\\<b\\>%0Ac>)", EntitiesToMarkdown(link.String(), link.Entities()))
+}
+
+func TestMessageToHTML(t *testing.T) {
+	assert.Equal(t, "<b>text</b>", MessageToHTML(&tgbotapi.Message{
+		Text:     "text",
+		Entities: []tgbotapi.MessageEntity{{Type: "bold", Offset: 0, Length: 4}},
+	}))
+	assert.Equal(t, "<i>caption</i>", MessageToHTML(&tgbotapi.Message{
+		Caption:         "caption",
+		CaptionEntities: []tgbotapi.MessageEntity{{Type: "italic", Offset: 0, Length: 7}},
+	}))
+	assert.Empty(t, MessageToHTML(nil))
+}
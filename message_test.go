@@ -4,6 +4,8 @@ import (
 	"strings"
 	"testing"
 
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -20,9 +22,136 @@ func TestSplitMessagesAgainstLengthLimitIntoMessageGroups(t *testing.T) {
 	}
 
 	batchSlice := SplitMessagesAgainstLengthLimitIntoMessageGroups(s)
-	require.Len(t, batchSlice, 3)
+	require.Len(t, batchSlice, 2)
 
-	require.Len(t, batchSlice[0], 3)
-	require.Len(t, batchSlice[1], 3)
-	require.Len(t, batchSlice[2], 2)
+	require.Len(t, batchSlice[0], 4)
+	require.Len(t, batchSlice[1], 4)
+
+	for _, batch := range batchSlice {
+		assert.LessOrEqual(t, MessageTextLength(strings.Join(batch, DefaultMessageGroupSeparator), ""), MessageLengthLimit)
+	}
+}
+
+func TestSplitMessageText(t *testing.T) {
+	t.Run("Short", func(t *testing.T) {
+		assert.Equal(t, []string{"hello"}, SplitMessageText("hello", ""))
+	})
+
+	t.Run("LineBreaks", func(t *testing.T) {
+		text := strings.Repeat("a", 6) + "\n" + strings.Repeat("b", 6) + "\n" + strings.Repeat("c", 6)
+
+		parts := splitMessageText(text, "", 15)
+		assert.Equal(t, []string{"aaaaaa\nbbbbbb\n", "cccccc"}, parts)
+	})
+
+	t.Run("Spaces", func(t *testing.T) {
+		parts := splitMessageText("hello world foo bar", "", 12)
+		assert.Equal(t, []string{"hello world ", "foo bar"}, parts)
+	})
+
+	t.Run("HardCut", func(t *testing.T) {
+		parts := splitMessageText(strings.Repeat("a", 10), "", 4)
+		assert.Equal(t, []string{"aaaa", "aaaa", "aa"}, parts)
+	})
+
+	t.Run("HTML", func(t *testing.T) {
+		text := `<b>bold <i>italic</i> <a href="https://example.com">link text</a></b> &amp; more`
+
+		parts := splitMessageText(text, tgbotapi.ModeHTML, 16)
+		assert.Equal(t, []string{
+			`<b>bold <i>italic</i> </b>`,
+			`<b><a href="https://example.com">link text</a></b> &amp; more`,
+		}, parts)
+
+		for _, part := range parts {
+			assert.Empty(t, openFormattingTokens(tokenizeMessageText(part, tgbotapi.ModeHTML)))
+		}
+	})
+
+	t.Run("TrailingTags", func(t *testing.T) {
+		parts := splitMessageText("<b>aaaa</b>\n", tgbotapi.ModeHTML, 4)
+		assert.Equal(t, []string{"<b>aaaa</b>\n"}, parts)
+	})
+}
+
+func TestMessageResponseMessageConfigs(t *testing.T) {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("a", "b")))
+
+	configs := NewMessage(1, "hello").WithReplyMarkup(keyboard).messageConfigs()
+	require.Len(t, configs, 1)
+	assert.Equal(t, keyboard, configs[0].ReplyMarkup)
+
+	text := strings.Repeat(strings.Repeat("a", 99)+"\n", 50)
+
+	configs = NewMessage(1, text).WithReplyMarkup(keyboard).messageConfigs()
+	require.Len(t, configs, 2)
+	assert.Nil(t, configs[0].ReplyMarkup)
+	assert.Equal(t, keyboard, configs[1].ReplyMarkup)
+	assert.Equal(t, text, configs[0].Text+configs[1].Text)
+	assert.LessOrEqual(t, len(configs[0].Text), MessageLengthLimit)
+}
+
+func TestMessageTextLength(t *testing.T) {
+	assert.Equal(t, 5, UTF16Length("hello"))
+	assert.Equal(t, 2, UTF16Length("你好"))
+	assert.Equal(t, 4, UTF16Length("👍👍"))
+
+	assert.Equal(t, 10, MessageTextLength("<b>hello</b> &lt;👍&gt;", tgbotapi.ModeHTML))
+	assert.Equal(t, 2, MessageTextLength("&#128077;", tgbotapi.ModeHTML))
+	assert.Equal(t, 2, MessageTextLength(`<a href="x>y">hi</a>`, tgbotapi.ModeHTML))
+	assert.Equal(t, 12, MessageTextLength("<b>hello</b>", ""))
+
+	assert.Equal(t, 19, MessageTextLength(`*bold* _italic_ __u__ \. ||s|| ~x~`, tgbotapi.ModeMarkdownV2))
+	assert.Equal(t, 4, MessageTextLength("[link](https://example.com/\\))", tgbotapi.ModeMarkdownV2))
+	assert.Equal(t, 13, MessageTextLength("```go\nfmt.Print(*a)```", tgbotapi.ModeMarkdownV2))
+	assert.Equal(t, 5, MessageTextLength(">quote", tgbotapi.ModeMarkdownV2))
+}
+
+func TestSplitMessageTextUTF16(t *testing.T) {
+	t.Run("Emoji", func(t *testing.T) {
+		parts := splitMessageText(strings.Repeat("👍", 5), "", 4)
+		assert.Equal(t, []string{"👍👍", "👍👍", "👍"}, parts)
+	})
+
+	t.Run("MarkdownV2", func(t *testing.T) {
+		parts := splitMessageText("*bold text* [link text](https://example.com)", tgbotapi.ModeMarkdownV2, 10)
+		assert.Equal(t, []string{
+			"*bold text* ",
+			"[link text](https://example.com)",
+		}, parts)
+
+		parts = splitMessageText("```go\naaaa\nbbbb\n```", tgbotapi.ModeMarkdownV2, 6)
+		assert.Equal(t, []string{
+			"```go\naaaa\n```",
+			"```go\nbbbb\n```",
+		}, parts)
+	})
+}
+
+func TestSplitMessagesAgainstLengthLimitIntoMessageGroupsWithParseMode(t *testing.T) {
+	s := []string{
+		"<b>" + strings.Repeat("a", 1005) + "</b>",
+		"<b>" + strings.Repeat("b", 1005) + "</b>",
+		"<b>" + strings.Repeat("c", 1005) + "</b>",
+		"<b>" + strings.Repeat("d", 1005) + "</b>",
+	}
+
+	require.Len(t, SplitMessagesAgainstLengthLimitIntoMessageGroupsWithParseMode(s, "\n", tgbotapi.ModeHTML), 1)
+	require.Len(t, SplitMessagesAgainstLengthLimitIntoMessageGroups([]string{strings.Repeat("👍", 1100), strings.Repeat("👍", 1100)}), 2)
+
+	t.Run("ExactLimit", func(t *testing.T) {
+		// 2047 + 2 + 2047 fits exactly
+		fits := []string{strings.Repeat("a", 2047), strings.Repeat("b", 2047)}
+		batchSlice := SplitMessagesAgainstLengthLimitIntoMessageGroupsWithParseMode(fits, "\n\n", "")
+		require.Len(t, batchSlice, 1)
+		assert.Equal(t, MessageLengthLimit, MessageTextLength(strings.Join(batchSlice[0], "\n\n"), ""))
+
+		// one more unit doesn't
+		overflows := []string{strings.Repeat("a", 2048), strings.Repeat("b", 2047)}
+		require.Len(t, SplitMessagesAgainstLengthLimitIntoMessageGroupsWithParseMode(overflows, "\n\n", ""), 2)
+
+		// the tags of the separator are not counted in HTML
+		html := []string{"<b>" + strings.Repeat("a", 2047) + "</b>", "<b>" + strings.Repeat("b", 2047) + "</b>"}
+		require.Len(t, SplitMessagesAgainstLengthLimitIntoMessageGroupsWithParseMode(html, "\n<i></i>\n", tgbotapi.ModeHTML), 1)
+	})
 }
@@ -12,6 +12,12 @@ func (k Key) Format(params ...interface{}) string {
 
 // Common keys.
 
+const (
+	// BotNamespace1 is the prefix of the keys of a bot hosted by BotManager.
+	// params: bot name
+	BotNamespace1 Key = "bot/%s/"
+)
+
 const (
 	// SessionDeleteLaterMessagesForActor1 is the key for deleting later messages for actor.
 	// params: actor id
@@ -25,10 +31,65 @@ const (
 	CallbackQueryData2 Key = "callback_query/button_data/%s/%s"
 )
 
+// Update keys.
+const (
+	// UpdateDeduplication2 is the key for marking an update as seen.
+	// params: bot id, update id
+	UpdateDeduplication2 Key = "update/deduplication/%d/%d"
+
+	// UpdatePollingOffset1 is the key for the offset of the next update to poll.
+	// params: bot id
+	UpdatePollingOffset1 Key = "update/polling_offset/%d"
+)
+
+// Chat keys.
+const (
+	// ChatMigration2 is the key for the supergroup that a group migrated to.
+	// params: bot id, chat id of the group
+	ChatMigration2 Key = "chat/migration/%d/%d"
+
+	// ChatMigrationHooked2 is the key that marks the hooks of a migration as done.
+	// params: bot id, chat id of the group
+	ChatMigrationHooked2 Key = "chat/migration/hooked/%d/%d"
+
+	// ChatMigrationHooking2 is the key that elects the caller of the hooks of a migration.
+	// params: bot id, chat id of the group
+	ChatMigrationHooking2 Key = "chat/migration/hooking/%d/%d"
+)
+
+// MediaGroup keys.
+const (
+	// MediaGroupMessages3 is the key for buffering the messages of a media group.
+	// params: bot id, chat id, media group id
+	MediaGroupMessages3 Key = "media_group/messages/%d/%d/%s" // List
+
+	// MediaGroupCollector3 is the key for electing the one that collects the
+	// buffered messages of a media group.
+	// params: bot id, chat id, media group id
+	MediaGroupCollector3 Key = "media_group/collector/%d/%d/%s"
+
+	// MediaGroupDeadline3 is the key for the time when the media group is collected,
+	// pushed back by every buffered message.
+	// params: bot id, chat id, media group id
+	MediaGroupDeadline3 Key = "media_group/deadline/%d/%d/%s"
+)
+
 // Rate limits.
 
 const (
 	// CommandRateLimitLock2 is the key for command rate limit lock.
 	// params: command, platform, chat id
 	CommandRateLimitLock2 Key = "rate_limit/manual_recap/command:%s/group/%s/%s"
+
+	// SendRateLimitGlobal1 is the key for the global budget of sending messages.
+	// params: bot id
+	SendRateLimitGlobal1 Key = "rate_limit/send/%d/global"
+
+	// SendRateLimitChat2 is the key for the per chat budget of sending messages.
+	// params: bot id, chat id or channel username
+	SendRateLimitChat2 Key = "rate_limit/send/%d/chat/%s"
+
+	// SendRateLimitGroup2 is the key for the per group budget of sending messages.
+	// params: bot id, chat id or channel username
+	SendRateLimitGroup2 Key = "rate_limit/send/%d/group/%s"
 )
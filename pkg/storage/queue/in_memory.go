@@ -3,6 +3,7 @@ package queue
 import (
 	"context"
 	"sync"
+	"time"
 )
 
 var _ Queue = (*InMemoryQueue)(nil)
@@ -10,12 +11,14 @@ var _ Queue = (*InMemoryQueue)(nil)
 type InMemoryQueue struct {
 	mutex sync.Mutex
 
-	items map[string][]string
+	items    map[string][]string
+	expiries map[string]time.Time
 }
 
 func NewInMemoryQueue() *InMemoryQueue {
 	return &InMemoryQueue{
-		items: make(map[string][]string, 0),
+		items:    make(map[string][]string, 0),
+		expiries: make(map[string]time.Time, 0),
 	}
 }
 
@@ -23,6 +26,8 @@ func (q *InMemoryQueue) Push(_ context.Context, group string, data string) error
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
 
+	q.dropExpired()
+
 	q.items[group] = append(q.items[group], data)
 
 	return nil
@@ -32,6 +37,8 @@ func (q *InMemoryQueue) Pop(_ context.Context, group string) (string, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
 
+	q.dropExpired()
+
 	if len(q.items[group]) == 0 {
 		return "", nil
 	}
@@ -46,8 +53,35 @@ func (q *InMemoryQueue) PopAll(_ context.Context, group string) ([]string, error
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
 
+	q.dropExpired()
+
 	data := append([]string{}, q.items[group]...)
-	q.items[group] = make([]string, 0)
+	delete(q.items, group)
+	delete(q.expiries, group)
 
 	return data, nil
 }
+
+func (q *InMemoryQueue) Expire(_ context.Context, group string, ttl time.Duration) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.expiries[group] = time.Now().Add(ttl)
+	q.dropExpired()
+
+	return nil
+}
+
+// dropExpired drops the expired groups, must be called with the mutex held.
+func (q *InMemoryQueue) dropExpired() {
+	now := time.Now()
+
+	for group, expiry := range q.expiries {
+		if now.Before(expiry) {
+			continue
+		}
+
+		delete(q.items, group)
+		delete(q.expiries, group)
+	}
+}
@@ -0,0 +1,36 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryQueueExpire(t *testing.T) {
+	q := NewInMemoryQueue()
+	ctx := context.Background()
+
+	require.NoError(t, q.Push(ctx, "expiring", "a"))
+	require.NoError(t, q.Expire(ctx, "expiring", 20*time.Millisecond))
+	require.NoError(t, q.Push(ctx, "kept", "b"))
+
+	time.Sleep(30 * time.Millisecond)
+
+	values, err := q.PopAll(ctx, "expiring")
+	require.NoError(t, err)
+	assert.Empty(t, values)
+
+	values, err = q.PopAll(ctx, "kept")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, values)
+
+	require.NoError(t, q.Push(ctx, "dropped", "c"))
+	require.NoError(t, q.Expire(ctx, "dropped", 0))
+
+	data, err := q.Pop(ctx, "dropped")
+	require.NoError(t, err)
+	assert.Empty(t, data)
+}
@@ -0,0 +1,38 @@
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+var _ Queue = (*PrefixedQueue)(nil)
+
+// PrefixedQueue prepends the prefix to every group, so that multiple bots can
+// share the same underlying queue without interfering with each other.
+type PrefixedQueue struct {
+	queue  Queue
+	prefix string
+}
+
+func NewPrefixedQueue(queue Queue, prefix string) *PrefixedQueue {
+	return &PrefixedQueue{
+		queue:  queue,
+		prefix: prefix,
+	}
+}
+
+func (q *PrefixedQueue) Push(ctx context.Context, group string, data string) error {
+	return q.queue.Push(ctx, q.prefix+group, data)
+}
+
+func (q *PrefixedQueue) Pop(ctx context.Context, group string) (string, error) {
+	return q.queue.Pop(ctx, q.prefix+group)
+}
+
+func (q *PrefixedQueue) PopAll(ctx context.Context, group string) ([]string, error) {
+	return q.queue.PopAll(ctx, q.prefix+group)
+}
+
+func (q *PrefixedQueue) Expire(ctx context.Context, group string, ttl time.Duration) error {
+	return q.queue.Expire(ctx, q.prefix+group, ttl)
+}
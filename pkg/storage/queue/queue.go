@@ -1,9 +1,15 @@
 package queue
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Queue interface {
 	Push(context.Context, string, string) error
 	Pop(context.Context, string) (string, error)
 	PopAll(context.Context, string) ([]string, error)
+	// Expire drops the group once the ttl passed, unless it's expired again. The
+	// group is dropped right away for the non-positive ttl.
+	Expire(context.Context, string, time.Duration) error
 }
@@ -2,6 +2,7 @@ package queue
 
 import (
 	"context"
+	"time"
 
 	"github.com/redis/rueidis"
 )
@@ -88,3 +89,13 @@ func (q *RueidisQueue) PopAll(ctx context.Context, group string) ([]string, erro
 
 	return elems, nil
 }
+
+func (q *RueidisQueue) Expire(ctx context.Context, group string, ttl time.Duration) error {
+	pexpireCmd := q.rueidis.B().
+		Pexpire().
+		Key(group).
+		Milliseconds(ttl.Milliseconds()).
+		Build()
+
+	return q.rueidis.Do(ctx, pexpireCmd).Error()
+}
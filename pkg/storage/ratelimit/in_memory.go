@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ RateLimiter = (*InMemoryRateLimiter)(nil)
+
+type inMemoryRateLimitState struct {
+	tat          time.Time
+	blockedUntil time.Time
+}
+
+type InMemoryRateLimiter struct {
+	mutex sync.Mutex
+
+	states      map[string]*inMemoryRateLimitState
+	lastCleanup time.Time
+}
+
+func NewInMemoryRateLimiter() *InMemoryRateLimiter {
+	return &InMemoryRateLimiter{
+		states:      make(map[string]*inMemoryRateLimitState),
+		lastCleanup: time.Now(),
+	}
+}
+
+func (l *InMemoryRateLimiter) Reserve(_ context.Context, key string, limit int, per time.Duration) (time.Duration, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	l.cleanup(now)
+
+	if limit <= 0 || per <= 0 {
+		state, ok := l.states[key]
+		if !ok {
+			return 0, nil
+		}
+
+		return max(state.blockedUntil.Sub(now), 0), nil
+	}
+
+	state, ok := l.states[key]
+	if !ok {
+		state = &inMemoryRateLimitState{}
+		l.states[key] = state
+	}
+
+	scheduled, tat := gcra(now, state.tat, state.blockedUntil, limit, per)
+	state.tat = tat
+
+	return scheduled.Sub(now), nil
+}
+
+func (l *InMemoryRateLimiter) Block(_ context.Context, key string, d time.Duration) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	state, ok := l.states[key]
+	if !ok {
+		state = &inMemoryRateLimitState{}
+		l.states[key] = state
+	}
+
+	blockedUntil := time.Now().Add(d)
+	if blockedUntil.After(state.blockedUntil) {
+		state.blockedUntil = blockedUntil
+	}
+
+	return nil
+}
+
+// cleanup drops the states that no longer affect the scheduling, since there is
+// a key per chat.
+func (l *InMemoryRateLimiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < time.Minute {
+		return
+	}
+
+	l.lastCleanup = now
+
+	for key, state := range l.states {
+		if state.tat.Before(now) && state.blockedUntil.Before(now) {
+			delete(l.states, key)
+		}
+	}
+}
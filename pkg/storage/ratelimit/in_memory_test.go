@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRateLimiter(t *testing.T) {
+	t.Run("Burst", func(t *testing.T) {
+		l := NewInMemoryRateLimiter()
+
+		for i := 0; i < 3; i++ {
+			wait, err := l.Reserve(context.Background(), "burst", 3, time.Minute)
+			require.NoError(t, err)
+			assert.Zero(t, wait)
+		}
+
+		wait, err := l.Reserve(context.Background(), "burst", 3, time.Minute)
+		require.NoError(t, err)
+		assert.InDelta(t, 20*time.Second, wait, float64(time.Second))
+
+		wait, err = l.Reserve(context.Background(), "burst", 3, time.Minute)
+		require.NoError(t, err)
+		assert.InDelta(t, 40*time.Second, wait, float64(time.Second))
+	})
+
+	t.Run("Block", func(t *testing.T) {
+		l := NewInMemoryRateLimiter()
+
+		require.NoError(t, l.Block(context.Background(), "block", 5*time.Second))
+
+		wait, err := l.Reserve(context.Background(), "block", 30, time.Second)
+		require.NoError(t, err)
+		assert.InDelta(t, 5*time.Second, wait, float64(100*time.Millisecond))
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		l := NewInMemoryRateLimiter()
+
+		wait, err := l.Reserve(context.Background(), "unlimited", 0, time.Second)
+		require.NoError(t, err)
+		assert.Zero(t, wait)
+
+		require.NoError(t, l.Block(context.Background(), "unlimited", 5*time.Second))
+
+		wait, err = l.Reserve(context.Background(), "unlimited", 0, time.Second)
+		require.NoError(t, err)
+		assert.InDelta(t, 5*time.Second, wait, float64(100*time.Millisecond))
+	})
+}
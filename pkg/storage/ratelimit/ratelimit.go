@@ -0,0 +1,42 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimiter schedules the requests against rate limits, the requests exceeding
+// the limit are not rejected but scheduled to a later time.
+type RateLimiter interface {
+	// Reserve reserves one request in the limit of key that allows limit requests
+	// per period, returns how long to wait before the request can be sent. A zero
+	// limit doesn't limit the requests, only the blocks of key apply.
+	Reserve(ctx context.Context, key string, limit int, per time.Duration) (time.Duration, error)
+	// Block postpones all the requests of key for d, e.g. when Telegram responds
+	// with retry_after.
+	Block(ctx context.Context, key string, d time.Duration) error
+}
+
+// gcra computes the scheduled time of the request with the generic cell rate
+// algorithm, tat is the theoretical arrival time of the next request.
+func gcra(now, tat, blockedUntil time.Time, limit int, per time.Duration) (scheduled time.Time, newTAT time.Time) {
+	interval := per / time.Duration(limit)
+
+	if tat.Before(now) {
+		tat = now
+	}
+
+	// requests are allowed to burst up to limit in a period
+	scheduled = tat.Add(interval - per)
+	if scheduled.Before(now) {
+		scheduled = now
+	}
+	if scheduled.Before(blockedUntil) {
+		scheduled = blockedUntil
+	}
+	if tat.Before(scheduled) {
+		tat = scheduled
+	}
+
+	return scheduled, tat.Add(interval)
+}
@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+var _ RateLimiter = (*RueidisRateLimiter)(nil)
+
+// reserveScript is the same algorithm as gcra, in microseconds of the time of the
+// Redis server so that the replicas agree on the clock.
+var reserveScript = rueidis.NewLuaScript(`
+local interval = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+local blocked_until = tonumber(redis.call('GET', KEYS[2]) or 0)
+if tat < now then
+	tat = now
+end
+local scheduled = tat + interval - period
+if scheduled < now then
+	scheduled = now
+end
+if scheduled < blocked_until then
+	scheduled = blocked_until
+end
+if tat < scheduled then
+	tat = scheduled
+end
+tat = tat + interval
+redis.call('SET', KEYS[1], string.format('%d', tat), 'PX', math.ceil((tat - now) / 1000) + 1)
+return math.floor(scheduled - now)
+`)
+
+var blockScript = rueidis.NewLuaScript(`
+local d = tonumber(ARGV[1])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local blocked_until = tonumber(redis.call('GET', KEYS[1]) or 0)
+if now + d > blocked_until then
+	redis.call('SET', KEYS[1], string.format('%d', now + d), 'PX', math.ceil(d / 1000) + 1)
+end
+return 0
+`)
+
+var blockedScript = rueidis.NewLuaScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local blocked_until = tonumber(redis.call('GET', KEYS[1]) or 0)
+if blocked_until < now then
+	return 0
+end
+return math.floor(blocked_until - now)
+`)
+
+type RueidisRateLimiter struct {
+	rueidis rueidis.Client
+}
+
+func NewRueidisRateLimiter(client rueidis.Client) *RueidisRateLimiter {
+	return &RueidisRateLimiter{
+		rueidis: client,
+	}
+}
+
+func (l *RueidisRateLimiter) Reserve(ctx context.Context, key string, limit int, per time.Duration) (time.Duration, error) {
+	if limit <= 0 || per <= 0 {
+		waitMicroseconds, err := blockedScript.Exec(ctx, l.rueidis, []string{blockedKey(key)}, nil).AsInt64()
+		if err != nil {
+			return 0, err
+		}
+
+		return time.Duration(waitMicroseconds) * time.Microsecond, nil
+	}
+
+	interval := per / time.Duration(limit)
+
+	waitMicroseconds, err := reserveScript.Exec(ctx, l.rueidis,
+		[]string{budgetKey(key), blockedKey(key)},
+		[]string{formatMicroseconds(interval), formatMicroseconds(per)},
+	).AsInt64()
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(waitMicroseconds) * time.Microsecond, nil
+}
+
+func (l *RueidisRateLimiter) Block(ctx context.Context, key string, d time.Duration) error {
+	return blockScript.Exec(ctx, l.rueidis, []string{blockedKey(key)}, []string{formatMicroseconds(d)}).Error()
+}
+
+// budgetKey and blockedKey hash tag the key, so that both keys of the reserve
+// script land in the same slot of Redis Cluster.
+func budgetKey(key string) string {
+	return "{" + key + "}"
+}
+
+func blockedKey(key string) string {
+	return budgetKey(key) + "/blocked"
+}
+
+func formatMicroseconds(d time.Duration) string {
+	return strconv.FormatInt(d.Microseconds(), 10)
+}
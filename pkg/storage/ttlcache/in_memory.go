@@ -2,53 +2,48 @@ package ttlcache
 
 import (
 	"context"
-	"sync"
 	"time"
 
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/mo"
 )
 
-type InMemoryTTLCache struct {
-	mutex sync.Mutex
+var _ TTLCache = (*InMemoryTTLCache)(nil)
 
-	mCache      map[time.Duration]*cache.Cache
-	mKeyMapping map[string]*cache.Cache
+type InMemoryTTLCache struct {
+	cache *cache.Cache
 }
 
 func NewInMemoryTTLCache() *InMemoryTTLCache {
 	return &InMemoryTTLCache{
-		mCache:      make(map[time.Duration]*cache.Cache),
-		mKeyMapping: make(map[string]*cache.Cache),
+		cache: cache.New(cache.NoExpiration, time.Minute),
 	}
 }
 
 func (c *InMemoryTTLCache) Get(_ context.Context, key string) (mo.Option[string], error) {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-
-	if cache, ok := c.mKeyMapping[key]; ok {
-		if value, found := cache.Get(key); found {
-			str, _ := value.(string)
-			return mo.Some(str), nil
-		}
+	if value, found := c.cache.Get(key); found {
+		str, _ := value.(string)
+		return mo.Some(str), nil
 	}
 
 	return mo.None[string](), nil
 }
 
 func (c *InMemoryTTLCache) Set(_ context.Context, key string, value string, ttl time.Duration) error {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-
-	if foundCache, ok := c.mKeyMapping[key]; ok {
-		foundCache.Set(key, value, ttl)
-	} else {
-		cache := cache.New(ttl, time.Second)
-		cache.Set(key, value, ttl)
-		c.mCache[ttl] = cache
-		c.mKeyMapping[key] = cache
-	}
+	c.cache.Set(key, value, ttl)
 
 	return nil
 }
+
+func (c *InMemoryTTLCache) SetNX(_ context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		return false, ErrNonPositiveTTL
+	}
+
+	err := c.cache.Add(key, value, ttl)
+	if err != nil {
+		return false, nil //nolint:nilerr
+	}
+
+	return true, nil
+}
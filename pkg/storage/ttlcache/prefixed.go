@@ -0,0 +1,36 @@
+package ttlcache
+
+import (
+	"context"
+	"time"
+
+	"github.com/samber/mo"
+)
+
+var _ TTLCache = (*PrefixedTTLCache)(nil)
+
+// PrefixedTTLCache prepends the prefix to every key, so that multiple bots can
+// share the same underlying cache without interfering with each other.
+type PrefixedTTLCache struct {
+	cache  TTLCache
+	prefix string
+}
+
+func NewPrefixedTTLCache(cache TTLCache, prefix string) *PrefixedTTLCache {
+	return &PrefixedTTLCache{
+		cache:  cache,
+		prefix: prefix,
+	}
+}
+
+func (c *PrefixedTTLCache) Get(ctx context.Context, key string) (mo.Option[string], error) {
+	return c.cache.Get(ctx, c.prefix+key)
+}
+
+func (c *PrefixedTTLCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return c.cache.Set(ctx, c.prefix+key, value, ttl)
+}
+
+func (c *PrefixedTTLCache) SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	return c.cache.SetNX(ctx, c.prefix+key, value, ttl)
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/samber/mo"
 )
 
+var _ TTLCache = (*RueidisTTLCache)(nil)
+
 type RueidisTTLCache struct {
 	rueidis rueidis.Client
 }
@@ -51,3 +53,30 @@ func (c *RueidisTTLCache) Set(ctx context.Context, key string, value string, ttl
 
 	return nil
 }
+
+func (c *RueidisTTLCache) SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		return false, ErrNonPositiveTTL
+	}
+
+	// EX only takes whole seconds, and Redis rejects EX 0 for the ttl under 1s,
+	// the ttl under 1ms is rounded up to 1ms for the same reason
+	setCmd := c.rueidis.B().
+		Set().
+		Key(key).
+		Value(value).
+		Nx().
+		PxMilliseconds(max(ttl.Milliseconds(), 1)).
+		Build()
+
+	err := c.rueidis.Do(ctx, setCmd).Error()
+	if err != nil {
+		if rueidis.IsRedisNil(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
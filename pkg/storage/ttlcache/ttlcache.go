@@ -2,12 +2,20 @@ package ttlcache
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/samber/mo"
 )
 
+// ErrNonPositiveTTL is returned by SetNX for the ttl that would never expire the
+// key, or be rejected by Redis.
+var ErrNonPositiveTTL = errors.New("ttl must be positive")
+
 type TTLCache interface {
 	Get(context.Context, string) (mo.Option[string], error)
 	Set(context.Context, string, string, time.Duration) error
+	// SetNX sets the value only when the key doesn't exist, reports whether the value was set.
+	// The ttl must be positive.
+	SetNX(context.Context, string, string, time.Duration) (bool, error)
 }
@@ -0,0 +1,26 @@
+// Package tghtml works with the subset of HTML that Telegram supports as the HTML
+// parse mode of messages.
+//
+// https://core.telegram.org/bots/api#html-style
+package tghtml
+
+import "strings"
+
+var (
+	textEscaper      = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	attributeEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+)
+
+// Escape escapes <, > and & of the text, so that it's displayed as it is.
+func Escape(text string) string {
+	return textEscaper.Replace(text)
+}
+
+// EscapeAttribute escapes the value to be put in a double quoted attribute, the
+// double quotes are escaped in addition to Escape.
+func EscapeAttribute(value string) string {
+	return attributeEscaper.Replace(value)
+}
+
+// HTML is the HTML that is trusted, it's not escaped by Template.
+type HTML string
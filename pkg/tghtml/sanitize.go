@@ -0,0 +1,254 @@
+package tghtml
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// Error is a problem found in the HTML by Validate.
+type Error struct {
+	// Offset is the byte offset in the HTML where the problem is.
+	Offset  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("offset %d: %s", e.Offset, e.Message)
+}
+
+// supportedTags are the tags supported by Telegram, and the attributes allowed on them.
+var supportedTags = map[string][]string{
+	"b":          nil,
+	"strong":     nil,
+	"i":          nil,
+	"em":         nil,
+	"u":          nil,
+	"ins":        nil,
+	"s":          nil,
+	"strike":     nil,
+	"del":        nil,
+	"tg-spoiler": nil,
+	"span":       {"class"},
+	"a":          {"href"},
+	"tg-emoji":   {"emoji-id"},
+	"code":       {"class"},
+	"pre":        nil,
+	"blockquote": {"expandable"},
+}
+
+var (
+	matchTag       = regexp.MustCompile(`^<(/?)([a-zA-Z][a-zA-Z0-9-]*)((?:\s+(?:"[^"]*"|'[^']*'|[^<>"'])*?)?)\s*/?>`)
+	matchAttribute = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9-]*)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+)))?`)
+	matchEntity    = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|lt|gt|amp|quot);`)
+)
+
+type openTag struct {
+	name   string
+	offset int
+}
+
+type processor struct {
+	src      string
+	sanitize bool
+	sb       strings.Builder
+	open     []openTag
+	errs     []error
+}
+
+// Sanitize makes the HTML safe to be sent with the HTML parse mode: the tags and
+// attributes that Telegram doesn't support are escaped as text, so are the tags
+// nested in code and pre, stray < and & are escaped, closing tags without the
+// opening ones are dropped, and the tags left open are closed at the end.
+func Sanitize(html string) string {
+	p := &processor{src: html, sanitize: true}
+	p.process()
+
+	return p.sb.String()
+}
+
+// Validate reports the problems of the HTML that Telegram would reject or render
+// differently than expected, nil when the HTML is fine.
+func Validate(html string) error {
+	p := &processor{src: html}
+	p.process()
+
+	return errors.Join(p.errs...)
+}
+
+func (p *processor) fail(offset int, format string, args ...any) {
+	p.errs = append(p.errs, &Error{Offset: offset, Message: fmt.Sprintf(format, args...)})
+}
+
+func (p *processor) process() {
+	for i := 0; i < len(p.src); {
+		rest := p.src[i:]
+
+		switch rest[0] {
+		case '<':
+			match := matchTag.FindStringSubmatch(rest)
+			if match == nil {
+				p.fail(i, "unescaped <")
+				p.sb.WriteString("&lt;")
+				i++
+
+				continue
+			}
+
+			p.tag(i, match)
+			i += len(match[0])
+		case '>':
+			p.fail(i, "unescaped >")
+			p.sb.WriteString("&gt;")
+			i++
+		case '&':
+			entity := matchEntity.FindString(rest)
+			if entity == "" {
+				p.fail(i, "unescaped & or unsupported entity")
+				p.sb.WriteString("&amp;")
+				i++
+
+				continue
+			}
+
+			p.sb.WriteString(entity)
+			i += len(entity)
+		default:
+			next := strings.IndexAny(rest, "<>&")
+			if next < 0 {
+				next = len(rest)
+			}
+
+			p.sb.WriteString(rest[:next])
+			i += next
+		}
+	}
+
+	for j := len(p.open) - 1; j >= 0; j-- {
+		p.fail(p.open[j].offset, "<%s> is not closed", p.open[j].name)
+		p.sb.WriteString("</" + p.open[j].name + ">")
+	}
+}
+
+func (p *processor) tag(offset int, match []string) {
+	closing := match[1] == "/"
+	name := strings.ToLower(match[2])
+
+	allowedAttributes, supported := supportedTags[name]
+	if !supported {
+		p.fail(offset, "<%s> is not supported", name)
+		p.sb.WriteString(Escape(match[0]))
+
+		return
+	}
+	if !closing && p.inCode() && !(name == "code" && p.innermost() == "pre") {
+		p.fail(offset, "<%s> can't be nested in <%s>", name, p.innermost())
+		p.sb.WriteString(Escape(match[0]))
+
+		return
+	}
+	if closing {
+		p.close(offset, name, match[0])
+		return
+	}
+	if name == "blockquote" && lo.ContainsBy(p.open, func(tag openTag) bool { return tag.name == "blockquote" }) {
+		p.fail(offset, "<blockquote> can't be nested")
+		p.sb.WriteString(Escape(match[0]))
+
+		return
+	}
+
+	attributes, ok := p.attributes(offset, name, match[3], allowedAttributes)
+	if !ok {
+		p.sb.WriteString(Escape(match[0]))
+		return
+	}
+
+	p.open = append(p.open, openTag{name: name, offset: offset})
+	p.sb.WriteString("<" + name + attributes + ">")
+}
+
+func (p *processor) close(offset int, name string, raw string) {
+	_, index, ok := lo.FindLastIndexOf(p.open, func(tag openTag) bool {
+		return tag.name == name
+	})
+	if !ok {
+		if p.inCode() {
+			p.fail(offset, "</%s> can't be nested in <%s>", name, p.innermost())
+			p.sb.WriteString(Escape(raw))
+
+			return
+		}
+
+		p.fail(offset, "</%s> is not opened", name)
+
+		return
+	}
+
+	// close the tags opened inside in the first place
+	for j := len(p.open) - 1; j > index; j-- {
+		p.fail(p.open[j].offset, "<%s> is not closed before </%s>", p.open[j].name, name)
+		p.sb.WriteString("</" + p.open[j].name + ">")
+	}
+
+	p.open = p.open[:index]
+	p.sb.WriteString("</" + name + ">")
+}
+
+func (p *processor) attributes(offset int, name string, raw string, allowed []string) (string, bool) {
+	var sb strings.Builder
+
+	for _, attribute := range matchAttribute.FindAllStringSubmatch(raw, -1) {
+		key := strings.ToLower(attribute[1])
+		value := attribute[2] + attribute[3] + attribute[4]
+
+		if !lo.Contains(allowed, key) {
+			p.fail(offset, "attribute %s of <%s> is not supported", key, name)
+			continue
+		}
+
+		switch {
+		case name == "span" && value != "tg-spoiler":
+			p.fail(offset, `<span> is only supported with class="tg-spoiler"`)
+			return "", false
+		case name == "code" && !strings.HasPrefix(value, "language-"):
+			p.fail(offset, `class of <code> must be language-*`)
+			continue
+		case name == "blockquote":
+			sb.WriteString(" expandable")
+			continue
+		}
+
+		sb.WriteString(" " + key + `="` + EscapeAttribute(value) + `"`)
+	}
+
+	switch {
+	case name == "span" && sb.Len() == 0:
+		p.fail(offset, `<span> is only supported with class="tg-spoiler"`)
+		return "", false
+	case name == "a" && !strings.Contains(sb.String(), "href="):
+		p.fail(offset, "<a> requires href")
+		return "", false
+	case name == "tg-emoji" && !strings.Contains(sb.String(), "emoji-id="):
+		p.fail(offset, "<tg-emoji> requires emoji-id")
+		return "", false
+	}
+
+	return sb.String(), true
+}
+
+func (p *processor) innermost() string {
+	if len(p.open) == 0 {
+		return ""
+	}
+
+	return p.open[len(p.open)-1].name
+}
+
+func (p *processor) inCode() bool {
+	innermost := p.innermost()
+	return innermost == "code" || innermost == "pre"
+}
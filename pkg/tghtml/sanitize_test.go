@@ -0,0 +1,62 @@
+package tghtml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscape(t *testing.T) {
+	assert.Equal(t, "&lt;b&gt; &amp;lt; &amp; \"", Escape(`<b> &lt; & "`))
+	assert.Equal(t, "&lt;b&gt; &amp; &quot;", EscapeAttribute(`<b> & "`))
+}
+
+func TestSanitize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{name: "Supported", html: `<b>bold</b> <i>italic</i> <a href="https://example.com">link</a>`, expected: `<b>bold</b> <i>italic</i> <a href="https://example.com">link</a>`},
+		{name: "Entities", html: "&lt;&gt;&amp;&quot;&#128077;&#x1F44D;", expected: "&lt;&gt;&amp;&quot;&#128077;&#x1F44D;"},
+		{name: "UnsupportedEntity", html: "a&nbsp;b", expected: "a&amp;nbsp;b"},
+		{name: "StraySymbols", html: "1 < 2 & 3 > 2", expected: "1 &lt; 2 &amp; 3 &gt; 2"},
+		{name: "UnsupportedTags", html: "<div>text</div><br/>", expected: "&lt;div&gt;text&lt;/div&gt;&lt;br/&gt;"},
+		{name: "UnsupportedAttributes", html: `<b style="color: red">bold</b>`, expected: "<b>bold</b>"},
+		{name: "Spoiler", html: `<span class="tg-spoiler">s</span><span>x</span>`, expected: `<span class="tg-spoiler">s</span>&lt;span&gt;x`},
+		{name: "QuotedAttributes", html: `<a href='https://example.com/?q="a"&b=1'>x</a>`, expected: `<a href="https://example.com/?q=&quot;a&quot;&amp;b=1">x</a>`},
+		{name: "GreaterThanInAttribute", html: `<a href="https://example.com/?a>b">x</a>`, expected: `<a href="https://example.com/?a&gt;b">x</a>`},
+		{name: "CodeLanguage", html: `<pre><code class="language-go">a <b>b</b></code></pre>`, expected: `<pre><code class="language-go">a &lt;b&gt;b&lt;/b&gt;</code></pre>`},
+		{name: "Unclosed", html: "<b><i>text", expected: "<b><i>text</i></b>"},
+		{name: "Misnested", html: "<b><i>text</b></i>", expected: "<b><i>text</i></b>"},
+		{name: "StrayClosing", html: "text</b>", expected: "text"},
+		{name: "NestedBlockquote", html: "<blockquote>a<blockquote expandable>b</blockquote></blockquote>", expected: "<blockquote>a&lt;blockquote expandable&gt;b</blockquote>"},
+		{name: "Expandable", html: "<blockquote expandable>a</blockquote>", expected: "<blockquote expandable>a</blockquote>"},
+		{name: "UpperCase", html: "<B>bold</B>", expected: "<b>bold</b>"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sanitized := Sanitize(tc.html)
+
+			assert.Equal(t, tc.expected, sanitized)
+			assert.NoError(t, Validate(sanitized))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(`<b>bold</b> &amp; <a href="https://example.com">link</a>`))
+
+	err := Validate("<div>a & b</div><b>")
+	require.Error(t, err)
+
+	var validationErr *Error
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, 0, validationErr.Offset)
+	assert.Contains(t, err.Error(), "<div> is not supported")
+	assert.Contains(t, err.Error(), "unescaped &")
+	assert.Contains(t, err.Error(), "<b> is not closed")
+}
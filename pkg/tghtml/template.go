@@ -0,0 +1,128 @@
+package tghtml
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"text/template"
+	"text/template/parse"
+)
+
+const escapeFuncName = "_tghtml_escape"
+
+// Template is a text/template that escapes the values interpolated by the actions
+// with EscapeAttribute like html/template does, so that they are displayed as they
+// are in both texts and attributes. Values of the HTML type are not escaped.
+//
+//	tmpl := tghtml.Must(tghtml.New("greeting").Parse(`<b>Hello, {{ .Name }}</b>`))
+type Template struct {
+	tmpl    *template.Template
+	escaped map[*parse.Tree]bool
+}
+
+// New allocates a new template with the name.
+func New(name string) *Template {
+	return &Template{
+		tmpl:    template.New(name).Funcs(template.FuncMap{escapeFuncName: escapeValue}),
+		escaped: make(map[*parse.Tree]bool),
+	}
+}
+
+// Must panics when err is not nil, like template.Must.
+func Must(t *Template, err error) *Template {
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+// Funcs adds the functions to the function map of the template, must be called
+// before Parse.
+func (t *Template) Funcs(funcMap template.FuncMap) *Template {
+	t.tmpl.Funcs(funcMap)
+	return t
+}
+
+// Parse parses the text as the body of the template.
+func (t *Template) Parse(text string) (*Template, error) {
+	_, err := t.tmpl.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tmpl := range t.tmpl.Templates() {
+		if tmpl.Tree == nil || t.escaped[tmpl.Tree] {
+			continue
+		}
+
+		escapeNode(tmpl.Tree.Root)
+		t.escaped[tmpl.Tree] = true
+	}
+
+	return t, nil
+}
+
+// Execute applies the template to the data, writing the output to w.
+func (t *Template) Execute(w io.Writer, data any) error {
+	return t.tmpl.Execute(w, data)
+}
+
+// ExecuteToString applies the template to the data and returns the output.
+func (t *Template) ExecuteToString(data any) (string, error) {
+	var buf bytes.Buffer
+
+	err := t.Execute(&buf, data)
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// escapeNode pipes the output of every action to the escape function.
+func escapeNode(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+
+		for _, child := range n.Nodes {
+			escapeNode(child)
+		}
+	case *parse.ActionNode:
+		// assignments print nothing
+		if len(n.Pipe.Decl) > 0 {
+			return
+		}
+
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+			NodeType: parse.NodeCommand,
+			Pos:      n.Pos,
+			Args:     []parse.Node{parse.NewIdentifier(escapeFuncName).SetTree(nil).SetPos(n.Pos)},
+		})
+	case *parse.IfNode:
+		escapeNode(n.List)
+		escapeNode(n.ElseList)
+	case *parse.RangeNode:
+		escapeNode(n.List)
+		escapeNode(n.ElseList)
+	case *parse.WithNode:
+		escapeNode(n.List)
+		escapeNode(n.ElseList)
+	}
+}
+
+func escapeValue(args ...any) string {
+	if len(args) == 1 {
+		switch v := args[0].(type) {
+		case nil:
+			return ""
+		case HTML:
+			return string(v)
+		}
+	}
+
+	return EscapeAttribute(fmt.Sprint(args...))
+}
@@ -0,0 +1,37 @@
+package tghtml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplate(t *testing.T) {
+	tmpl := Must(New("test").Funcs(map[string]any{
+		"upper": func(s string) string { return s + "!" },
+	}).Parse(
+		`{{ define "item" }}<i>{{ . }}</i>{{ end }}` +
+			`<b>{{ .Name }}</b> <a href="{{ .URL }}">{{ .Trusted }}</a>` +
+			`{{ $greeting := "<hi>" }}{{ upper $greeting }}` +
+			`{{ range .Items }}{{ template "item" . }}{{ end }}` +
+			`{{ if .Missing }}{{ .Missing }}{{ else }}{{ .Name }}{{ end }}`,
+	))
+
+	output, err := tmpl.ExecuteToString(map[string]any{
+		"Name":    `<Tom & "Jerry">`,
+		"URL":     `https://example.com/?a="1"&b=2`,
+		"Trusted": HTML("<u>trusted</u>"),
+		"Items":   []string{"<1>", "2"},
+		"Missing": nil,
+	})
+	require.NoError(t, err)
+	assert.Equal(t,
+		`<b>&lt;Tom &amp; &quot;Jerry&quot;&gt;</b> <a href="https://example.com/?a=&quot;1&quot;&amp;b=2"><u>trusted</u></a>`+
+			`&lt;hi&gt;!`+
+			`<i>&lt;1&gt;</i><i>2</i>`+
+			`&lt;Tom &amp; &quot;Jerry&quot;&gt;`,
+		output,
+	)
+	assert.NoError(t, Validate(output))
+}
@@ -10,6 +10,8 @@ type MessageResponse struct {
 
 	deleteLaterForUserID int64
 	deleteLaterChatID    int64
+
+	onSent func(c *Context, message *tgbotapi.Message)
 }
 
 func NewMessage(chatID int64, message string) MessageResponse {
@@ -50,6 +52,61 @@ func (r MessageResponse) WithDeleteLater(userID int64, chatID int64) MessageResp
 	return r
 }
 
+// OnSent sets the callback called with every sent message, e.g. to store the
+// message ids for later edits or pinning, the parts of the overlong text are sent
+// as separate messages.
+func (r MessageResponse) OnSent(onSent func(c *Context, message *tgbotapi.Message)) MessageResponse {
+	r.onSent = onSent
+	return r
+}
+
+// WithText sets the text and the entities built by the TextBuilder, the parse
+// mode is cleared as the entities format the text instead.
+func (r MessageResponse) WithText(text *TextBuilder) MessageResponse {
+	r.messageConfig.Text = text.String()
+	r.messageConfig.Entities = text.Entities()
+	r.messageConfig.ParseMode = ""
+
+	return r
+}
+
+// messageConfigs splits the overlong text into multiple messages, the reply markup
+// is attached to the last one.
+func (r MessageResponse) messageConfigs() []tgbotapi.MessageConfig {
+	if MessageTextLength(r.messageConfig.Text, r.messageConfig.ParseMode) <= MessageLengthLimit {
+		return []tgbotapi.MessageConfig{r.messageConfig}
+	}
+
+	var (
+		parts         []string
+		partsEntities [][]tgbotapi.MessageEntity
+	)
+
+	if len(r.messageConfig.Entities) > 0 {
+		parts, partsEntities = splitMessageTextWithEntities(r.messageConfig.Text, r.messageConfig.Entities, MessageLengthLimit)
+	} else {
+		parts = SplitMessageText(r.messageConfig.Text, r.messageConfig.ParseMode)
+	}
+
+	configs := make([]tgbotapi.MessageConfig, 0, len(parts))
+
+	for i, part := range parts {
+		config := r.messageConfig
+		config.Text = part
+
+		if partsEntities != nil {
+			config.Entities = partsEntities[i]
+		}
+		if i < len(parts)-1 {
+			config.ReplyMarkup = nil
+		}
+
+		configs = append(configs, config)
+	}
+
+	return configs
+}
+
 type EditMessageResponse struct {
 	textConfig         *tgbotapi.EditMessageTextConfig
 	mediaConfig        *tgbotapi.EditMessageMediaConfig
@@ -58,6 +115,29 @@ type EditMessageResponse struct {
 	liveLocationConfig *tgbotapi.EditMessageLiveLocationConfig
 }
 
+// chattables returns the edits in the order they are requested.
+func (r EditMessageResponse) chattables() []tgbotapi.Chattable {
+	chattables := make([]tgbotapi.Chattable, 0, 1)
+
+	if r.mediaConfig != nil {
+		chattables = append(chattables, r.mediaConfig)
+	}
+	if r.replyMarkupConfig != nil {
+		chattables = append(chattables, r.replyMarkupConfig)
+	}
+	if r.liveLocationConfig != nil {
+		chattables = append(chattables, r.liveLocationConfig)
+	}
+	if r.textConfig != nil {
+		chattables = append(chattables, r.textConfig)
+	}
+	if r.captionConfig != nil {
+		chattables = append(chattables, r.captionConfig)
+	}
+
+	return chattables
+}
+
 func NewEditMessageText(chatID int64, messageID int, text string) EditMessageResponse {
 	return EditMessageResponse{
 		textConfig: lo.ToPtr(tgbotapi.NewEditMessageText(chatID, messageID, text)),
@@ -81,6 +161,30 @@ func (r EditMessageResponse) WithParseModeHTML() EditMessageResponse {
 	return r
 }
 
+// WithText sets the text and the entities built by the TextBuilder to the text
+// edit, or the caption edit if there is no text edit, the parse mode is cleared
+// as the entities format the text instead.
+func (r EditMessageResponse) WithText(text *TextBuilder) EditMessageResponse {
+	if r.textConfig != nil {
+		textConfig := *r.textConfig
+		textConfig.Text = text.String()
+		textConfig.Entities = text.Entities()
+		textConfig.ParseMode = ""
+		r.textConfig = &textConfig
+
+		return r
+	}
+	if r.captionConfig != nil {
+		captionConfig := *r.captionConfig
+		captionConfig.Caption = text.String()
+		captionConfig.CaptionEntities = text.Entities()
+		captionConfig.ParseMode = ""
+		r.captionConfig = &captionConfig
+	}
+
+	return r
+}
+
 func (r EditMessageResponse) WithInlineReplyMarkup(inlineMarkup tgbotapi.InlineKeyboardMarkup) EditMessageResponse {
 	if r.textConfig != nil {
 		r.textConfig.ReplyMarkup = &inlineMarkup
@@ -0,0 +1,123 @@
+package tgo
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// endpointConfig is the request of a Bot API method that tgbotapi lacks a config
+// for, such as PinChatMessageConfig.
+type endpointConfig interface {
+	method() string
+	params() (tgbotapi.Params, error)
+}
+
+// ActionResponse executes a Bot API method other than sending a message, such as
+// deleting or pinning a message, sending a chat action, setting reactions, and
+// forwarding or copying a message.
+type ActionResponse struct {
+	chattable tgbotapi.Chattable
+	endpoint  endpointConfig
+
+	onSent func(c *Context, message *tgbotapi.Message)
+}
+
+func NewDeleteMessage(chatID int64, messageID int) ActionResponse {
+	return ActionResponse{chattable: tgbotapi.NewDeleteMessage(chatID, messageID)}
+}
+
+// NewPinMessage pins the message, silently unless notify is set.
+func NewPinMessage(chatID int64, messageID int, notify bool) ActionResponse {
+	config := NewPinChatMessageConfig(chatID, messageID)
+	config.DisableNotification = !notify
+
+	return ActionResponse{endpoint: config}
+}
+
+func NewUnpinMessage(chatID int64, messageID int) ActionResponse {
+	return ActionResponse{endpoint: NewUnpinChatMessageConfig(chatID, messageID)}
+}
+
+// NewChatAction tells the user that something is happening on the bot's side, the
+// action is one of tgbotapi.ChatTyping, tgbotapi.ChatUploadPhoto and the likes,
+// and lasts 5 seconds or until the next message is sent.
+func NewChatAction(chatID int64, action string) ActionResponse {
+	return ActionResponse{chattable: tgbotapi.NewChatAction(chatID, action)}
+}
+
+func NewForwardMessage(chatID int64, fromChatID int64, messageID int) ActionResponse {
+	return ActionResponse{chattable: tgbotapi.NewForward(chatID, fromChatID, messageID)}
+}
+
+// NewCopyMessage copies the message without the link to the original one, the
+// sent message only carries the message id.
+func NewCopyMessage(chatID int64, fromChatID int64, messageID int) ActionResponse {
+	return ActionResponse{chattable: tgbotapi.NewCopyMessage(chatID, fromChatID, messageID)}
+}
+
+// NewSetReaction reacts to the message with the emojis, no emojis remove the
+// reactions of the bot.
+func NewSetReaction(chatID int64, messageID int, emojis ...string) ActionResponse {
+	return ActionResponse{endpoint: NewSetMessageReactionConfig(chatID, messageID, emojis...)}
+}
+
+// OnSent sets the callback called with the forwarded or copied message.
+func (r ActionResponse) OnSent(onSent func(c *Context, message *tgbotapi.Message)) ActionResponse {
+	r.onSent = onSent
+	return r
+}
+
+// triggeringMessage returns the message that the update is about, the message
+// with the inline keyboard for callback queries.
+func (c *Context) triggeringMessage() *tgbotapi.Message {
+	switch {
+	case c.Update.Message != nil:
+		return c.Update.Message
+	case c.Update.CallbackQuery != nil:
+		return c.Update.CallbackQuery.Message
+	case c.Update.ChannelPost != nil:
+		return c.Update.ChannelPost
+	default:
+		return nil
+	}
+}
+
+func (c *Context) NewDeleteMessage(messageID int) ActionResponse {
+	return NewDeleteMessage(c.Update.FromChat().ID, messageID)
+}
+
+// NewDeleteTriggeringMessage deletes the message that the update is about, e.g.
+// the command, or the message with the pressed inline keyboard button.
+func (c *Context) NewDeleteTriggeringMessage() ActionResponse {
+	message := c.triggeringMessage()
+	if message == nil || message.Chat == nil {
+		return ActionResponse{}
+	}
+
+	return NewDeleteMessage(message.Chat.ID, message.MessageID)
+}
+
+func (c *Context) NewPinMessage(messageID int, notify bool) ActionResponse {
+	return NewPinMessage(c.Update.FromChat().ID, messageID, notify)
+}
+
+func (c *Context) NewUnpinMessage(messageID int) ActionResponse {
+	return NewUnpinMessage(c.Update.FromChat().ID, messageID)
+}
+
+func (c *Context) NewChatAction(action string) ActionResponse {
+	return NewChatAction(c.Update.FromChat().ID, action)
+}
+
+// NewForwardMessage forwards the message of the chat to the chat toChatID.
+func (c *Context) NewForwardMessage(toChatID int64, messageID int) ActionResponse {
+	return NewForwardMessage(toChatID, c.Update.FromChat().ID, messageID)
+}
+
+// NewCopyMessage copies the message of the chat to the chat toChatID.
+func (c *Context) NewCopyMessage(toChatID int64, messageID int) ActionResponse {
+	return NewCopyMessage(toChatID, c.Update.FromChat().ID, messageID)
+}
+
+func (c *Context) NewSetReaction(messageID int, emojis ...string) ActionResponse {
+	return NewSetReaction(c.Update.FromChat().ID, messageID, emojis...)
+}
@@ -0,0 +1,93 @@
+package tgo
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionResponse(t *testing.T) {
+	var (
+		mutex  sync.Mutex
+		params = make(map[string]map[string]string)
+	)
+
+	ctx, fake := newTestContext(t, func(method string, r *http.Request) (any, *tgbotapi.APIResponse) {
+		require.NoError(t, r.ParseForm())
+
+		mutex.Lock()
+		params[method] = map[string]string{}
+		for key := range r.PostForm {
+			params[method][key] = r.PostForm.Get(key)
+		}
+		mutex.Unlock()
+
+		switch method {
+		case "forwardMessage":
+			return sentMessageResult(20), nil
+		case "copyMessage":
+			return map[string]any{"message_id": 21}, nil
+		default:
+			return true, nil
+		}
+	})
+
+	var copied *tgbotapi.Message
+
+	messages, err := executeResponse(ctx, Responses{
+		ctx.NewDeleteTriggeringMessage(),
+		ctx.NewPinMessage(11, false),
+		ctx.NewUnpinMessage(11),
+		ctx.NewChatAction(tgbotapi.ChatTyping),
+		ctx.NewForwardMessage(3, 10),
+		ctx.NewCopyMessage(3, 10).OnSent(func(_ *Context, message *tgbotapi.Message) {
+			copied = message
+		}),
+		ctx.NewSetReaction(10, "👍"),
+		ActionResponse{},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"deleteMessage",
+		"pinChatMessage",
+		"unpinChatMessage",
+		"sendChatAction",
+		"forwardMessage",
+		"copyMessage",
+		"setMessageReaction",
+	}, fake.calledMethods())
+	assert.True(t, ctx.IsAborted())
+
+	require.Len(t, messages, 2)
+	assert.Equal(t, 20, messages[0].MessageID)
+	assert.Equal(t, 21, messages[1].MessageID)
+	require.NotNil(t, copied)
+	assert.Equal(t, 21, copied.MessageID)
+
+	assert.Equal(t, "10", params["deleteMessage"]["message_id"])
+	assert.Equal(t, "true", params["pinChatMessage"]["disable_notification"])
+	assert.Equal(t, "typing", params["sendChatAction"]["action"])
+	assert.Equal(t, "2", params["forwardMessage"]["from_chat_id"])
+	assert.Equal(t, "3", params["copyMessage"]["chat_id"])
+	assert.JSONEq(t, `[{"type":"emoji","emoji":"👍"}]`, params["setMessageReaction"]["reaction"])
+}
+
+func TestActionResponseError(t *testing.T) {
+	ctx, _ := newTestContext(t, func(string, *http.Request) (any, *tgbotapi.APIResponse) {
+		return nil, &tgbotapi.APIResponse{Ok: false, ErrorCode: 400, Description: "Bad Request: message can't be deleted"}
+	})
+
+	_, err := executeResponse(ctx, ctx.NewDeleteMessage(10))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrMessageCantBeDeleted)
+
+	var botAPIErr *BotAPIError
+	require.True(t, errors.As(err, &botAPIErr))
+	assert.Equal(t, 400, botAPIErr.Code)
+}
@@ -0,0 +1,278 @@
+package tgo
+
+import (
+	"io"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// FileFromReader returns the file to be uploaded from the reader, the reader is
+// read only once, so the sends of it are not retried.
+func FileFromReader(name string, reader io.Reader) tgbotapi.RequestFileData {
+	return tgbotapi.FileReader{Name: name, Reader: reader}
+}
+
+// FileFromPath returns the local file to be uploaded.
+func FileFromPath(path string) tgbotapi.RequestFileData {
+	return tgbotapi.FilePath(path)
+}
+
+// FileFromURL returns the file that Telegram downloads from the URL.
+func FileFromURL(url string) tgbotapi.RequestFileData {
+	return tgbotapi.FileURL(url)
+}
+
+// FileFromID returns the file already stored on the Telegram servers.
+func FileFromID(fileID string) tgbotapi.RequestFileData {
+	return tgbotapi.FileID(fileID)
+}
+
+// MediaResponse sends a photo, document, video, animation, audio, voice, sticker,
+// location, venue, contact or dice.
+type MediaResponse struct {
+	config tgbotapi.Chattable
+
+	deleteLaterForUserID int64
+	deleteLaterChatID    int64
+
+	onSent func(c *Context, message *tgbotapi.Message)
+}
+
+func NewPhoto(chatID int64, file tgbotapi.RequestFileData) MediaResponse {
+	return MediaResponse{config: tgbotapi.NewPhoto(chatID, file)}
+}
+
+func NewDocument(chatID int64, file tgbotapi.RequestFileData) MediaResponse {
+	return MediaResponse{config: tgbotapi.NewDocument(chatID, file)}
+}
+
+func NewVideo(chatID int64, file tgbotapi.RequestFileData) MediaResponse {
+	return MediaResponse{config: tgbotapi.NewVideo(chatID, file)}
+}
+
+func NewAnimation(chatID int64, file tgbotapi.RequestFileData) MediaResponse {
+	return MediaResponse{config: tgbotapi.NewAnimation(chatID, file)}
+}
+
+func NewAudio(chatID int64, file tgbotapi.RequestFileData) MediaResponse {
+	return MediaResponse{config: tgbotapi.NewAudio(chatID, file)}
+}
+
+func NewVoice(chatID int64, file tgbotapi.RequestFileData) MediaResponse {
+	return MediaResponse{config: tgbotapi.NewVoice(chatID, file)}
+}
+
+func NewSticker(chatID int64, file tgbotapi.RequestFileData) MediaResponse {
+	return MediaResponse{config: tgbotapi.NewSticker(chatID, file)}
+}
+
+func NewLocation(chatID int64, latitude float64, longitude float64) MediaResponse {
+	return MediaResponse{config: tgbotapi.NewLocation(chatID, latitude, longitude)}
+}
+
+func NewVenue(chatID int64, title string, address string, latitude float64, longitude float64) MediaResponse {
+	return MediaResponse{config: tgbotapi.NewVenue(chatID, title, address, latitude, longitude)}
+}
+
+func NewContact(chatID int64, phoneNumber string, firstName string) MediaResponse {
+	return MediaResponse{config: tgbotapi.NewContact(chatID, phoneNumber, firstName)}
+}
+
+// NewDice sends a dice with the emoji, one of 🎲, 🎯, 🏀, ⚽, 🎳 or 🎰, an empty
+// emoji stands for 🎲.
+func NewDice(chatID int64, emoji string) MediaResponse {
+	return MediaResponse{config: tgbotapi.NewDiceWithEmoji(chatID, emoji)}
+}
+
+// WithConfig replaces the config to send, e.g. to set the fields that the
+// builders don't cover.
+func (r MediaResponse) WithConfig(config tgbotapi.Chattable) MediaResponse {
+	r.config = config
+	return r
+}
+
+// WithCaption sets the caption of the photo, document, video, animation, audio or
+// voice, the caption longer than CaptionLengthLimit is sent as a reply to the
+// media instead.
+func (r MediaResponse) WithCaption(caption string) MediaResponse {
+	return r.updateCaption(func(c *string, _ *string, entities *[]tgbotapi.MessageEntity) {
+		*c = caption
+		*entities = nil
+	})
+}
+
+// WithCaptionText sets the caption and the entities built by the TextBuilder, the
+// parse mode is cleared as the entities format the caption instead.
+func (r MediaResponse) WithCaptionText(text *TextBuilder) MediaResponse {
+	return r.updateCaption(func(caption *string, parseMode *string, entities *[]tgbotapi.MessageEntity) {
+		*caption = text.String()
+		*parseMode = ""
+		*entities = text.Entities()
+	})
+}
+
+func (r MediaResponse) WithParseModeHTML() MediaResponse {
+	return r.updateCaption(func(_ *string, parseMode *string, _ *[]tgbotapi.MessageEntity) {
+		*parseMode = tgbotapi.ModeHTML
+	})
+}
+
+func (r MediaResponse) WithParseModeMarkdownV2() MediaResponse {
+	return r.updateCaption(func(_ *string, parseMode *string, _ *[]tgbotapi.MessageEntity) {
+		*parseMode = tgbotapi.ModeMarkdownV2
+	})
+}
+
+func (r MediaResponse) WithReplyTo(replyToMessageID int) MediaResponse {
+	return r.updateBaseChat(func(chat *tgbotapi.BaseChat) {
+		chat.ReplyToMessageID = replyToMessageID
+	})
+}
+
+func (r MediaResponse) WithReplyMarkup(replyMarkup any) MediaResponse {
+	return r.updateBaseChat(func(chat *tgbotapi.BaseChat) {
+		chat.ReplyMarkup = replyMarkup
+	})
+}
+
+func (r MediaResponse) WithDeleteLater(userID int64, chatID int64) MediaResponse {
+	r.deleteLaterForUserID = userID
+	r.deleteLaterChatID = chatID
+
+	return r
+}
+
+// OnSent sets the callback called with the sent message, and the caption sent as
+// a reply when it's too long to be a caption.
+func (r MediaResponse) OnSent(onSent func(c *Context, message *tgbotapi.Message)) MediaResponse {
+	r.onSent = onSent
+	return r
+}
+
+// updateBaseChat calls update with the BaseChat of a copy of the config.
+func (r MediaResponse) updateBaseChat(update func(chat *tgbotapi.BaseChat)) MediaResponse {
+	switch c := r.config.(type) {
+	case tgbotapi.PhotoConfig:
+		update(&c.BaseChat)
+		r.config = c
+	case tgbotapi.DocumentConfig:
+		update(&c.BaseChat)
+		r.config = c
+	case tgbotapi.VideoConfig:
+		update(&c.BaseChat)
+		r.config = c
+	case tgbotapi.AnimationConfig:
+		update(&c.BaseChat)
+		r.config = c
+	case tgbotapi.AudioConfig:
+		update(&c.BaseChat)
+		r.config = c
+	case tgbotapi.VoiceConfig:
+		update(&c.BaseChat)
+		r.config = c
+	case tgbotapi.StickerConfig:
+		update(&c.BaseChat)
+		r.config = c
+	case tgbotapi.LocationConfig:
+		update(&c.BaseChat)
+		r.config = c
+	case tgbotapi.VenueConfig:
+		update(&c.BaseChat)
+		r.config = c
+	case tgbotapi.ContactConfig:
+		update(&c.BaseChat)
+		r.config = c
+	case tgbotapi.DiceConfig:
+		update(&c.BaseChat)
+		r.config = c
+	}
+
+	return r
+}
+
+// updateCaption calls update with the caption fields of a copy of the config,
+// the configs without a caption are left untouched.
+func (r MediaResponse) updateCaption(update func(caption *string, parseMode *string, entities *[]tgbotapi.MessageEntity)) MediaResponse {
+	switch c := r.config.(type) {
+	case tgbotapi.PhotoConfig:
+		update(&c.Caption, &c.ParseMode, &c.CaptionEntities)
+		r.config = c
+	case tgbotapi.DocumentConfig:
+		update(&c.Caption, &c.ParseMode, &c.CaptionEntities)
+		r.config = c
+	case tgbotapi.VideoConfig:
+		update(&c.Caption, &c.ParseMode, &c.CaptionEntities)
+		r.config = c
+	case tgbotapi.AnimationConfig:
+		update(&c.Caption, &c.ParseMode, &c.CaptionEntities)
+		r.config = c
+	case tgbotapi.AudioConfig:
+		update(&c.Caption, &c.ParseMode, &c.CaptionEntities)
+		r.config = c
+	case tgbotapi.VoiceConfig:
+		update(&c.Caption, &c.ParseMode, &c.CaptionEntities)
+		r.config = c
+	}
+
+	return r
+}
+
+// file returns the file of the config, nil for the configs without a file.
+func (r MediaResponse) file() tgbotapi.RequestFileData {
+	var file tgbotapi.RequestFileData
+
+	switch c := r.config.(type) {
+	case tgbotapi.PhotoConfig:
+		file = c.File
+	case tgbotapi.DocumentConfig:
+		file = c.File
+	case tgbotapi.VideoConfig:
+		file = c.File
+	case tgbotapi.AnimationConfig:
+		file = c.File
+	case tgbotapi.AudioConfig:
+		file = c.File
+	case tgbotapi.VoiceConfig:
+		file = c.File
+	case tgbotapi.StickerConfig:
+		file = c.File
+	}
+
+	return file
+}
+
+// chattable returns the config to send, and the message carrying the caption
+// when it's too long to be a caption.
+func (r MediaResponse) chattable() (tgbotapi.Chattable, *MessageResponse) {
+	var (
+		caption   string
+		parseMode string
+		entities  []tgbotapi.MessageEntity
+		chatID    int64
+	)
+
+	r.updateCaption(func(c *string, p *string, e *[]tgbotapi.MessageEntity) {
+		caption, parseMode, entities = *c, *p, *e
+	})
+
+	if MessageTextLength(caption, parseMode) <= CaptionLengthLimit {
+		return r.config, nil
+	}
+
+	r.updateBaseChat(func(chat *tgbotapi.BaseChat) {
+		chatID = chat.ChatID
+	})
+
+	overflow := NewMessage(chatID, caption)
+	overflow.messageConfig.ParseMode = parseMode
+	overflow.messageConfig.Entities = entities
+	overflow.deleteLaterForUserID = r.deleteLaterForUserID
+	overflow.deleteLaterChatID = r.deleteLaterChatID
+	overflow.onSent = r.onSent
+
+	withoutCaption := r.updateCaption(func(c *string, p *string, e *[]tgbotapi.MessageEntity) {
+		*c, *p, *e = "", "", nil
+	})
+
+	return withoutCaption.config, &overflow
+}
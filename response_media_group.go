@@ -0,0 +1,211 @@
+package tgo
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/samber/lo"
+)
+
+const (
+	mediaGroupMinItems = 2
+	mediaGroupMaxItems = 10
+)
+
+// Errors of the media groups that Telegram refuses to send, returned by
+// MediaGroupResponse.Validate.
+var (
+	ErrMediaGroupSize             = errors.New("media group must consist of 2 to 10 items")
+	ErrMediaGroupMixedTypes       = errors.New("documents and audios can only be grouped with the same type, photos with videos")
+	ErrMediaGroupUnsupportedMedia = errors.New("media group only supports photos, videos, audios and documents")
+	ErrMediaGroupCaptionTooLong   = errors.New("caption of media group item is too long")
+)
+
+// MediaGroupResponse sends an album of photos and videos, audios or documents.
+type MediaGroupResponse struct {
+	config tgbotapi.MediaGroupConfig
+
+	deleteLaterForUserID int64
+	deleteLaterChatID    int64
+
+	onSent func(c *Context, message *tgbotapi.Message)
+}
+
+func NewMediaGroup(chatID int64) MediaGroupResponse {
+	return MediaGroupResponse{config: tgbotapi.NewMediaGroup(chatID, nil)}
+}
+
+func (r MediaGroupResponse) AddPhoto(file tgbotapi.RequestFileData, caption string) MediaGroupResponse {
+	media := tgbotapi.NewInputMediaPhoto(file)
+	media.Caption = caption
+
+	return r.AddMedia(media)
+}
+
+func (r MediaGroupResponse) AddVideo(file tgbotapi.RequestFileData, caption string) MediaGroupResponse {
+	media := tgbotapi.NewInputMediaVideo(file)
+	media.Caption = caption
+
+	return r.AddMedia(media)
+}
+
+func (r MediaGroupResponse) AddAudio(file tgbotapi.RequestFileData, caption string) MediaGroupResponse {
+	media := tgbotapi.NewInputMediaAudio(file)
+	media.Caption = caption
+
+	return r.AddMedia(media)
+}
+
+func (r MediaGroupResponse) AddDocument(file tgbotapi.RequestFileData, caption string) MediaGroupResponse {
+	media := tgbotapi.NewInputMediaDocument(file)
+	media.Caption = caption
+
+	return r.AddMedia(media)
+}
+
+// AddMedia adds a tgbotapi.InputMediaPhoto, tgbotapi.InputMediaVideo,
+// tgbotapi.InputMediaAudio or tgbotapi.InputMediaDocument to the group.
+func (r MediaGroupResponse) AddMedia(media any) MediaGroupResponse {
+	r.config.Media = append(slices.Clip(r.config.Media), media)
+	return r
+}
+
+// WithParseModeHTML sets the parse mode of the captions of all the items.
+func (r MediaGroupResponse) WithParseModeHTML() MediaGroupResponse {
+	return r.updateItems(func(media *tgbotapi.BaseInputMedia) {
+		media.ParseMode = tgbotapi.ModeHTML
+	})
+}
+
+// WithParseModeMarkdownV2 sets the parse mode of the captions of all the items.
+func (r MediaGroupResponse) WithParseModeMarkdownV2() MediaGroupResponse {
+	return r.updateItems(func(media *tgbotapi.BaseInputMedia) {
+		media.ParseMode = tgbotapi.ModeMarkdownV2
+	})
+}
+
+func (r MediaGroupResponse) WithReplyTo(replyToMessageID int) MediaGroupResponse {
+	r.config.ReplyToMessageID = replyToMessageID
+	return r
+}
+
+// WithDeleteLater deletes every sent item of the group later.
+func (r MediaGroupResponse) WithDeleteLater(userID int64, chatID int64) MediaGroupResponse {
+	r.deleteLaterForUserID = userID
+	r.deleteLaterChatID = chatID
+
+	return r
+}
+
+// OnSent sets the callback called with every sent item of the group.
+func (r MediaGroupResponse) OnSent(onSent func(c *Context, message *tgbotapi.Message)) MediaGroupResponse {
+	r.onSent = onSent
+	return r
+}
+
+// Validate checks the group against the rules of Telegram, 2 to 10 items, photos
+// and videos may be mixed, while audios and documents are only grouped with the
+// same type, and the captions must fit in CaptionLengthLimit.
+func (r MediaGroupResponse) Validate() error {
+	if len(r.config.Media) < mediaGroupMinItems || len(r.config.Media) > mediaGroupMaxItems {
+		return fmt.Errorf("%w, got %d", ErrMediaGroupSize, len(r.config.Media))
+	}
+
+	types := make([]string, 0, len(r.config.Media))
+
+	for i, item := range r.config.Media {
+		media, mediaType, ok := baseInputMediaOf(item)
+		if !ok {
+			return fmt.Errorf("%w, item %d is %T", ErrMediaGroupUnsupportedMedia, i, item)
+		}
+		if MessageTextLength(media.Caption, media.ParseMode) > CaptionLengthLimit {
+			return fmt.Errorf("%w, item %d", ErrMediaGroupCaptionTooLong, i)
+		}
+
+		types = append(types, mediaType)
+	}
+
+	types = lo.Uniq(types)
+	if len(types) > 1 && !lo.Every([]string{"photo", "video"}, types) {
+		return fmt.Errorf("%w, got %v", ErrMediaGroupMixedTypes, types)
+	}
+
+	return nil
+}
+
+// MediaGroupConfig returns the validated config to send with
+// BotAPI.SendMediaGroup, which returns the sent messages.
+func (r MediaGroupResponse) MediaGroupConfig() (tgbotapi.MediaGroupConfig, error) {
+	err := r.Validate()
+	if err != nil {
+		return tgbotapi.MediaGroupConfig{}, err
+	}
+
+	return r.config, nil
+}
+
+// updateItems calls update with the BaseInputMedia of the copies of the items.
+func (r MediaGroupResponse) updateItems(update func(media *tgbotapi.BaseInputMedia)) MediaGroupResponse {
+	items := slices.Clone(r.config.Media)
+
+	for i, item := range items {
+		switch m := item.(type) {
+		case tgbotapi.InputMediaPhoto:
+			update(&m.BaseInputMedia)
+			items[i] = m
+		case tgbotapi.InputMediaVideo:
+			update(&m.BaseInputMedia)
+			items[i] = m
+		case tgbotapi.InputMediaAudio:
+			update(&m.BaseInputMedia)
+			items[i] = m
+		case tgbotapi.InputMediaDocument:
+			update(&m.BaseInputMedia)
+			items[i] = m
+		}
+	}
+
+	r.config.Media = items
+
+	return r
+}
+
+// needsUpload reports whether any of the items has a file to upload, and whether
+// any of them is read from a reader, which can't be sent again.
+func (r MediaGroupResponse) needsUpload() (bool, bool) {
+	var needsUpload, fromReader bool
+
+	for _, item := range r.config.Media {
+		media, _, ok := baseInputMediaOf(item)
+		if !ok || media.Media == nil {
+			continue
+		}
+		if media.Media.NeedsUpload() {
+			needsUpload = true
+		}
+		if _, ok := media.Media.(tgbotapi.FileReader); ok {
+			fromReader = true
+		}
+	}
+
+	return needsUpload, fromReader
+}
+
+// baseInputMediaOf returns the BaseInputMedia of the item and the type of the
+// media, photo, video, audio or document.
+func baseInputMediaOf(item any) (tgbotapi.BaseInputMedia, string, bool) {
+	switch m := item.(type) {
+	case tgbotapi.InputMediaPhoto:
+		return m.BaseInputMedia, "photo", true
+	case tgbotapi.InputMediaVideo:
+		return m.BaseInputMedia, "video", true
+	case tgbotapi.InputMediaAudio:
+		return m.BaseInputMedia, "audio", true
+	case tgbotapi.InputMediaDocument:
+		return m.BaseInputMedia, "document", true
+	default:
+		return tgbotapi.BaseInputMedia{}, "", false
+	}
+}
@@ -0,0 +1,97 @@
+package tgo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaGroupResponse(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		group := NewMediaGroup(1).
+			AddPhoto(FileFromID("photo"), "<b>photo</b>").
+			AddVideo(FileFromURL("https://example.com/video.mp4"), "").
+			WithParseModeHTML().
+			WithReplyTo(2)
+
+		config, err := group.MediaGroupConfig()
+		require.NoError(t, err)
+		require.Len(t, config.Media, 2)
+		assert.Equal(t, 2, config.ReplyToMessageID)
+
+		photo, ok := config.Media[0].(tgbotapi.InputMediaPhoto)
+		require.True(t, ok)
+		assert.Equal(t, "<b>photo</b>", photo.Caption)
+		assert.Equal(t, tgbotapi.ModeHTML, photo.ParseMode)
+
+		needsUpload, fromReader := group.needsUpload()
+		assert.False(t, needsUpload)
+		assert.False(t, fromReader)
+	})
+
+	t.Run("Size", func(t *testing.T) {
+		group := NewMediaGroup(1).AddPhoto(FileFromID("photo"), "")
+		require.ErrorIs(t, group.Validate(), ErrMediaGroupSize)
+
+		for range 10 {
+			group = group.AddPhoto(FileFromID("photo"), "")
+		}
+
+		require.ErrorIs(t, group.Validate(), ErrMediaGroupSize)
+	})
+
+	t.Run("MixedTypes", func(t *testing.T) {
+		group := NewMediaGroup(1).
+			AddPhoto(FileFromID("photo"), "").
+			AddDocument(FileFromID("document"), "")
+		require.ErrorIs(t, group.Validate(), ErrMediaGroupMixedTypes)
+
+		group = NewMediaGroup(1).
+			AddAudio(FileFromID("audio"), "").
+			AddAudio(FileFromID("audio"), "")
+		require.NoError(t, group.Validate())
+	})
+
+	t.Run("UnsupportedMedia", func(t *testing.T) {
+		group := NewMediaGroup(1).
+			AddPhoto(FileFromID("photo"), "").
+			AddMedia(tgbotapi.NewInputMediaAnimation(FileFromID("animation")))
+		require.ErrorIs(t, group.Validate(), ErrMediaGroupUnsupportedMedia)
+	})
+
+	t.Run("CaptionTooLong", func(t *testing.T) {
+		group := NewMediaGroup(1).
+			AddPhoto(FileFromID("photo"), "").
+			AddPhoto(FileFromID("photo"), strings.Repeat("a", CaptionLengthLimit+1))
+		require.ErrorIs(t, group.Validate(), ErrMediaGroupCaptionTooLong)
+	})
+
+	t.Run("Immutable", func(t *testing.T) {
+		base := NewMediaGroup(1).AddPhoto(FileFromID("photo"), "")
+
+		first := base.AddPhoto(FileFromID("first"), "")
+		second := base.AddPhoto(FileFromID("second"), "").WithParseModeHTML()
+
+		firstPhoto, _, _ := baseInputMediaOf(first.config.Media[1])
+		assert.Equal(t, tgbotapi.FileID("first"), firstPhoto.Media)
+
+		basePhoto, _, _ := baseInputMediaOf(base.config.Media[0])
+		assert.Empty(t, basePhoto.ParseMode)
+		assert.Len(t, second.config.Media, 2)
+	})
+
+	t.Run("Upload", func(t *testing.T) {
+		group := NewMediaGroup(1).
+			AddDocument(FileFromPath("a.pdf"), "").
+			AddDocument(FileFromReader("b.pdf", bytes.NewReader(nil)), "")
+
+		needsUpload, fromReader := group.needsUpload()
+		assert.True(t, needsUpload)
+		assert.True(t, fromReader)
+		assert.Nil(t, webhookReplyChattable(group))
+	})
+}
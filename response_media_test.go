@@ -0,0 +1,80 @@
+package tgo
+
+import (
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaResponse(t *testing.T) {
+	t.Run("Caption", func(t *testing.T) {
+		photo := NewPhoto(1, FileFromID("file_id")).
+			WithCaption("<b>caption</b>").
+			WithParseModeHTML().
+			WithReplyTo(2)
+
+		chattable, captionOverflow := photo.chattable()
+		require.Nil(t, captionOverflow)
+
+		config, ok := chattable.(tgbotapi.PhotoConfig)
+		require.True(t, ok)
+		assert.Equal(t, "<b>caption</b>", config.Caption)
+		assert.Equal(t, tgbotapi.ModeHTML, config.ParseMode)
+		assert.Equal(t, 2, config.ReplyToMessageID)
+		assert.Equal(t, tgbotapi.FileID("file_id"), photo.file())
+	})
+
+	t.Run("CaptionText", func(t *testing.T) {
+		document := NewDocument(1, FileFromPath("document.pdf")).
+			WithParseModeHTML().
+			WithCaptionText(Text().Bold("caption"))
+
+		chattable, _ := document.chattable()
+
+		config, ok := chattable.(tgbotapi.DocumentConfig)
+		require.True(t, ok)
+		assert.Equal(t, "caption", config.Caption)
+		assert.Empty(t, config.ParseMode)
+		assert.Equal(t, []tgbotapi.MessageEntity{{Type: "bold", Offset: 0, Length: 7}}, config.CaptionEntities)
+	})
+
+	t.Run("CaptionOverflow", func(t *testing.T) {
+		caption := strings.Repeat("a", CaptionLengthLimit+1)
+
+		video := NewVideo(1, FileFromURL("https://example.com/video.mp4")).
+			WithCaption(caption).
+			WithParseModeMarkdownV2().
+			WithDeleteLater(2, 1)
+
+		chattable, captionOverflow := video.chattable()
+		require.NotNil(t, captionOverflow)
+
+		config, ok := chattable.(tgbotapi.VideoConfig)
+		require.True(t, ok)
+		assert.Empty(t, config.Caption)
+		assert.Empty(t, config.ParseMode)
+
+		assert.Equal(t, caption, captionOverflow.messageConfig.Text)
+		assert.Equal(t, tgbotapi.ModeMarkdownV2, captionOverflow.messageConfig.ParseMode)
+		assert.Equal(t, int64(1), captionOverflow.messageConfig.ChatID)
+		assert.Equal(t, int64(2), captionOverflow.deleteLaterForUserID)
+	})
+
+	t.Run("WithoutCaption", func(t *testing.T) {
+		location := NewLocation(1, 1.5, 2.5).
+			WithCaption("ignored").
+			WithReplyMarkup(tgbotapi.NewRemoveKeyboard(false))
+
+		chattable, captionOverflow := location.chattable()
+		require.Nil(t, captionOverflow)
+		assert.Nil(t, location.file())
+
+		config, ok := chattable.(tgbotapi.LocationConfig)
+		require.True(t, ok)
+		assert.InDelta(t, 1.5, config.Latitude, 0)
+		assert.Equal(t, tgbotapi.NewRemoveKeyboard(false), config.ReplyMarkup)
+	})
+}
@@ -0,0 +1,155 @@
+package tgo
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TextBuilder builds the plain text of a message along with the entities that
+// format it, as an alternative to the parse modes, so nothing needs to be escaped.
+// The offsets and lengths of the entities are counted in UTF-16 code units as
+// Telegram requires.
+//
+//	text := tgo.Text().
+//		Bold("Hello").
+//		Plain(", ").
+//		Mention(user).
+//		Plain("! See ").
+//		Link("the docs", "https://core.telegram.org/bots/api").
+//		Newline().
+//		Code("go test ./...")
+//
+//	return c.NewMessage("").WithText(text), nil
+type TextBuilder struct {
+	text     strings.Builder
+	length   int
+	entities []tgbotapi.MessageEntity
+}
+
+// Text creates a TextBuilder.
+func Text() *TextBuilder {
+	return &TextBuilder{
+		entities: make([]tgbotapi.MessageEntity, 0),
+	}
+}
+
+// String returns the plain text built.
+func (b *TextBuilder) String() string {
+	return b.text.String()
+}
+
+// Entities returns the entities of the text built.
+func (b *TextBuilder) Entities() []tgbotapi.MessageEntity {
+	return b.entities
+}
+
+// Len returns the length of the text built in UTF-16 code units.
+func (b *TextBuilder) Len() int {
+	return b.length
+}
+
+// Plain appends the text without formatting.
+func (b *TextBuilder) Plain(text string) *TextBuilder {
+	b.text.WriteString(text)
+	b.length += UTF16Length(text)
+
+	return b
+}
+
+// Newline appends a line break.
+func (b *TextBuilder) Newline() *TextBuilder {
+	return b.Plain("\n")
+}
+
+// Entity appends the text formatted by the entity, the offset and length of the
+// entity are filled in.
+func (b *TextBuilder) Entity(text string, entity tgbotapi.MessageEntity) *TextBuilder {
+	return b.Styled(entity, func(b *TextBuilder) {
+		b.Plain(text)
+	})
+}
+
+// Styled formats everything appended by build with the entity, the entities can
+// be nested this way.
+//
+//	tgo.Text().Styled(tgbotapi.MessageEntity{Type: "bold"}, func(b *tgo.TextBuilder) {
+//		b.Plain("bold and ").Italic("italic")
+//	})
+func (b *TextBuilder) Styled(entity tgbotapi.MessageEntity, build func(b *TextBuilder)) *TextBuilder {
+	offset := b.length
+	index := len(b.entities)
+
+	// the outer entity goes before the nested ones
+	b.entities = append(b.entities, entity)
+
+	build(b)
+
+	if b.length == offset {
+		// Telegram rejects empty entities
+		b.entities = append(b.entities[:index], b.entities[index+1:]...)
+		return b
+	}
+
+	b.entities[index].Offset = offset
+	b.entities[index].Length = b.length - offset
+
+	return b
+}
+
+func (b *TextBuilder) Bold(text string) *TextBuilder {
+	return b.Entity(text, tgbotapi.MessageEntity{Type: string(MessageEntityTypeBold)})
+}
+
+func (b *TextBuilder) Italic(text string) *TextBuilder {
+	return b.Entity(text, tgbotapi.MessageEntity{Type: string(MessageEntityTypeItalic)})
+}
+
+func (b *TextBuilder) Underline(text string) *TextBuilder {
+	return b.Entity(text, tgbotapi.MessageEntity{Type: string(MessageEntityTypeUnderline)})
+}
+
+func (b *TextBuilder) Strikethrough(text string) *TextBuilder {
+	return b.Entity(text, tgbotapi.MessageEntity{Type: string(MessageEntityTypeStrikethrough)})
+}
+
+func (b *TextBuilder) Spoiler(text string) *TextBuilder {
+	return b.Entity(text, tgbotapi.MessageEntity{Type: string(MessageEntityTypeSpoiler)})
+}
+
+func (b *TextBuilder) Blockquote(text string) *TextBuilder {
+	return b.Entity(text, tgbotapi.MessageEntity{Type: string(MessageEntityTypeBlockquote)})
+}
+
+func (b *TextBuilder) Code(text string) *TextBuilder {
+	return b.Entity(text, tgbotapi.MessageEntity{Type: string(MessageEntityTypeCode)})
+}
+
+// Pre appends the code block, language is optional.
+func (b *TextBuilder) Pre(text string, language string) *TextBuilder {
+	return b.Entity(text, tgbotapi.MessageEntity{Type: string(MessageEntityTypePre), Language: language})
+}
+
+// Link appends the text that links to the url.
+func (b *TextBuilder) Link(text string, url string) *TextBuilder {
+	return b.Entity(text, tgbotapi.MessageEntity{Type: string(MessageEntityTypeTextLink), URL: url})
+}
+
+// Mention appends the full name of the user that mentions the user, which works
+// for the users without username as well.
+func (b *TextBuilder) Mention(user *tgbotapi.User) *TextBuilder {
+	if user == nil {
+		return b
+	}
+
+	return b.TextMention(FullNameFromFirstAndLastName(user.FirstName, user.LastName), user)
+}
+
+// TextMention appends the text that mentions the user.
+func (b *TextBuilder) TextMention(text string, user *tgbotapi.User) *TextBuilder {
+	if user == nil {
+		return b.Plain(text)
+	}
+
+	return b.Entity(text, tgbotapi.MessageEntity{Type: string(MessageEntityTypeTextMention), User: user})
+}
@@ -0,0 +1,71 @@
+package tgo
+
+import (
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextBuilder(t *testing.T) {
+	user := &tgbotapi.User{ID: 1, FirstName: "Neko", LastName: "Meow"}
+
+	text := Text().
+		Plain("👋 ").
+		Bold("Hello").
+		Plain(", ").
+		Mention(user).
+		Newline().
+		Link("docs", "https://core.telegram.org/bots/api").
+		Plain(" ").
+		Pre("go test", "go").
+		Italic("").
+		Styled(tgbotapi.MessageEntity{Type: string(MessageEntityTypeBold)}, func(b *TextBuilder) {
+			b.Plain("b ").Italic("i")
+		})
+
+	assert.Equal(t, "👋 Hello, Neko Meow\ndocs go testb i", text.String())
+	assert.Equal(t, 35, text.Len())
+	assert.Equal(t, []tgbotapi.MessageEntity{
+		{Type: "bold", Offset: 3, Length: 5},
+		{Type: "text_mention", Offset: 10, Length: 9, User: user},
+		{Type: "text_link", Offset: 20, Length: 4, URL: "https://core.telegram.org/bots/api"},
+		{Type: "pre", Offset: 25, Length: 7, Language: "go"},
+		{Type: "bold", Offset: 32, Length: 3},
+		{Type: "italic", Offset: 34, Length: 1},
+	}, text.Entities())
+}
+
+func TestMessageResponseWithText(t *testing.T) {
+	text := Text().Bold("bold")
+
+	resp := NewMessage(1, "").WithParseModeHTML().WithText(text)
+	assert.Equal(t, "bold", resp.messageConfig.Text)
+	assert.Empty(t, resp.messageConfig.ParseMode)
+	assert.Len(t, resp.messageConfig.Entities, 1)
+
+	edit := NewEditMessageText(1, 1, "").WithText(text)
+	assert.Equal(t, "bold", edit.textConfig.Text)
+	assert.Len(t, edit.textConfig.Entities, 1)
+
+	t.Run("Split", func(t *testing.T) {
+		text := Text().
+			Plain(strings.Repeat("a", 4000)).
+			Bold(strings.Repeat("b", 49) + "\n" + strings.Repeat("c", 100)).
+			Italic("d")
+
+		configs := NewMessage(1, "").WithText(text).messageConfigs()
+		require.Len(t, configs, 2)
+
+		assert.Equal(t, strings.Repeat("a", 4000)+strings.Repeat("b", 49)+"\n", configs[0].Text)
+		assert.Equal(t, []tgbotapi.MessageEntity{{Type: "bold", Offset: 4000, Length: 50}}, configs[0].Entities)
+
+		assert.Equal(t, strings.Repeat("c", 100)+"d", configs[1].Text)
+		assert.Equal(t, []tgbotapi.MessageEntity{
+			{Type: "bold", Offset: 0, Length: 100},
+			{Type: "italic", Offset: 100, Length: 1},
+		}, configs[1].Entities)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"net"
 	"net/http"
 	"net/url"
@@ -14,6 +15,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -27,6 +29,7 @@ import (
 	"github.com/nekomeowww/tgo/pkg/i18n"
 	"github.com/nekomeowww/tgo/pkg/redis"
 	"github.com/nekomeowww/tgo/pkg/storage/queue"
+	"github.com/nekomeowww/tgo/pkg/storage/ratelimit"
 	"github.com/nekomeowww/tgo/pkg/storage/ttlcache"
 	"github.com/nekomeowww/xo"
 	"github.com/nekomeowww/xo/exp/channelx"
@@ -43,6 +46,25 @@ type botOptions struct {
 	queue       queue.Queue
 	ttlcache    ttlcache.TTLCache
 	i18n        *i18n.I18n
+
+	shutdownTimeout        time.Duration
+	updateDeduplicationTTL time.Duration
+	dropPendingUpdates     bool
+
+	webhookSecretToken      string
+	webhookWithoutTokenPath bool
+	webhookListener         net.Listener
+	webhookTLSCertFile      string
+	webhookTLSKeyFile       string
+	webhookCertificateFile  string
+	webhookMaxConnections   int
+	webhookIPAddress        string
+	withoutWebhookServer    bool
+	webhookReplyTimeout     time.Duration
+
+	rateLimiter    ratelimit.RateLimiter
+	sendRateLimits *SendRateLimits
+	retryPolicy    RetryPolicy
 }
 
 type CallOption func(*botOptions)
@@ -59,6 +81,84 @@ func WithWebhookPort(port string) CallOption {
 	}
 }
 
+// WithWebhookListener serves the webhook on the listener instead of listening
+// on the webhook port.
+func WithWebhookListener(listener net.Listener) CallOption {
+	return func(o *botOptions) {
+		o.webhookListener = listener
+	}
+}
+
+// WithWebhookTLS serves the webhook over HTTPS with the certificate and key files.
+func WithWebhookTLS(certFile, keyFile string) CallOption {
+	return func(o *botOptions) {
+		o.webhookTLSCertFile = certFile
+		o.webhookTLSKeyFile = keyFile
+	}
+}
+
+// WithWebhookCertificate uploads the public key certificate file to setWebhook
+// so that Telegram trusts the self-signed certificate of the webhook.
+func WithWebhookCertificate(certFile string) CallOption {
+	return func(o *botOptions) {
+		o.webhookCertificateFile = certFile
+	}
+}
+
+// WithWebhookMaxConnections sets the max_connections of setWebhook, the maximum
+// allowed number of simultaneous HTTPS connections to the webhook, 1-100.
+func WithWebhookMaxConnections(maxConnections int) CallOption {
+	return func(o *botOptions) {
+		o.webhookMaxConnections = maxConnections
+	}
+}
+
+// WithWebhookIPAddress sets the ip_address of setWebhook, the fixed IP address
+// which will be used to send webhook requests instead of the resolved one.
+func WithWebhookIPAddress(ipAddress string) CallOption {
+	return func(o *botOptions) {
+		o.webhookIPAddress = ipAddress
+	}
+}
+
+// WithoutWebhookServer sets the webhook but doesn't serve it, mount
+// Bot.WebhookHandler on Bot.WebhookPath of your own server instead.
+func WithoutWebhookServer() CallOption {
+	return func(o *botOptions) {
+		o.withoutWebhookServer = true
+	}
+}
+
+// WithWebhookReply lets the webhook request wait up to timeout for the handlers,
+// when they result in a single MessageResponse or EditMessageResponse, it's
+// written as the response body of the webhook request instead of calling the Bot
+// API, saving a round trip. Telegram doesn't report the result of such a call,
+// failures are therefore not logged. Timeout should be well below the webhook
+// timeout of Telegram, a few hundred milliseconds is a good start.
+func WithWebhookReply(timeout time.Duration) CallOption {
+	return func(o *botOptions) {
+		o.webhookReplyTimeout = timeout
+	}
+}
+
+// WithWebhookSecretToken asks Telegram to send the secret token in the
+// X-Telegram-Bot-Api-Secret-Token header of every webhook request, the requests
+// without the matched header are rejected.
+func WithWebhookSecretToken(secretToken string) CallOption {
+	return func(o *botOptions) {
+		o.webhookSecretToken = secretToken
+	}
+}
+
+// WithWebhookWithoutTokenPath serves the webhook on the path of the webhook URL as
+// it is instead of appending the bot token to it, so the token won't leak into
+// the logs of proxies. Requires WithWebhookSecretToken.
+func WithWebhookWithoutTokenPath() CallOption {
+	return func(o *botOptions) {
+		o.webhookWithoutTokenPath = true
+	}
+}
+
 func WithToken(token string) CallOption {
 	return func(o *botOptions) {
 		o.token = token
@@ -99,6 +199,34 @@ func WithRueidis(rueidis rueidis.Client) CallOption {
 	return func(o *botOptions) {
 		o.queue = queue.NewRueidisQueue(rueidis)
 		o.ttlcache = ttlcache.NewRueidisTTLCache(rueidis)
+		o.rateLimiter = ratelimit.NewRueidisRateLimiter(rueidis)
+	}
+}
+
+// WithRateLimiter sets the rate limiter that schedules the sends, use a shared one
+// e.g. ratelimit.NewRueidisRateLimiter so that multiple replicas share the budgets.
+func WithRateLimiter(rateLimiter ratelimit.RateLimiter) CallOption {
+	return func(o *botOptions) {
+		o.rateLimiter = rateLimiter
+	}
+}
+
+// WithSendRateLimits sets the budgets that the sends wait for, sends that exceed
+// the budgets are queued rather than rejected by Telegram with 429. Defaults to
+// DefaultSendRateLimits, the limits documented by Telegram, a zero RateLimit
+// disables the budget.
+func WithSendRateLimits(limits SendRateLimits) CallOption {
+	return func(o *botOptions) {
+		o.sendRateLimits = &limits
+	}
+}
+
+// WithRetryPolicy sets how MaySend, MayRequest and MayMakeRequest retry the
+// failed requests, defaults to DefaultRetryPolicy, NoRetry disables the retries.
+// Override it per call with WithRequestRetryPolicy.
+func WithRetryPolicy(policy RetryPolicy) CallOption {
+	return func(o *botOptions) {
+		o.retryPolicy = policy
 	}
 }
 
@@ -108,6 +236,32 @@ func WithI18n(i18n *i18n.I18n) CallOption {
 	}
 }
 
+// WithShutdownTimeout sets how long Bootstrap waits for in-flight handlers to
+// return after receiving a termination signal, defaults to 15 seconds.
+func WithShutdownTimeout(timeout time.Duration) CallOption {
+	return func(o *botOptions) {
+		o.shutdownTimeout = timeout
+	}
+}
+
+// WithUpdateDeduplication sets how long the seen update_id are remembered in the
+// TTL cache to drop the updates retried or replayed by Telegram, defaults to 1 hour,
+// zero or negative ttl disables the deduplication.
+func WithUpdateDeduplication(ttl time.Duration) CallOption {
+	return func(o *botOptions) {
+		o.updateDeduplicationTTL = ttl
+	}
+}
+
+// WithDropPendingUpdates drops the updates that Telegram queued while the bot was
+// offline, both for polling and webhook. Otherwise polling resumes from the
+// persisted offset and the pending updates are delivered.
+func WithDropPendingUpdates(drop bool) CallOption {
+	return func(o *botOptions) {
+		o.dropPendingUpdates = drop
+	}
+}
+
 type Bot struct {
 	*tgbotapi.BotAPI
 	*Dispatcher
@@ -116,20 +270,32 @@ type Bot struct {
 	logger *logger.Logger
 	i18n   *i18n.I18n
 
-	webhookServer     *http.Server
-	webhookUpdateChan chan tgbotapi.Update
-	updateChan        tgbotapi.UpdatesChannel
-	webhookStarted    bool
+	webhookServer  *http.Server
+	webhookHandler http.Handler
+	webhookUpdates *webhookUpdates
+	updateChan     tgbotapi.UpdatesChannel
+
+	alreadyStopped atomic.Bool
 
-	alreadyStopped bool
+	// ctx is cancelled once Stop returns, interrupting the backoffs of the retries
+	// and the waits for the send budgets of the handlers that were cut off
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	duplicatedUpdates atomic.Int64
 
 	puller *channelx.Puller[tgbotapi.Update]
 }
 
 func NewBot(callOpts ...CallOption) (*Bot, error) {
 	opts := &botOptions{
-		queue:    queue.NewInMemoryQueue(),
-		ttlcache: ttlcache.NewInMemoryTTLCache(),
+		queue:                  queue.NewInMemoryQueue(),
+		ttlcache:               ttlcache.NewInMemoryTTLCache(),
+		rateLimiter:            ratelimit.NewInMemoryRateLimiter(),
+		retryPolicy:            DefaultRetryPolicy,
+		sendRateLimits:         lo.ToPtr(DefaultSendRateLimits),
+		shutdownTimeout:        15 * time.Second,
+		updateDeduplicationTTL: time.Hour,
 	}
 
 	for _, callOpt := range callOpts {
@@ -139,6 +305,14 @@ func NewBot(callOpts ...CallOption) (*Bot, error) {
 	if opts.token == "" {
 		return nil, errors.New("must supply a valid telegram bot token in configs or environment variable")
 	}
+	if opts.webhookWithoutTokenPath && opts.webhookSecretToken == "" {
+		return nil, errors.New("must supply a webhook secret token to serve webhook without bot token in path")
+	}
+
+	err := validateWebhookSecretToken(opts.webhookSecretToken)
+	if err != nil {
+		return nil, err
+	}
 	if opts.logger == nil {
 		logger, err := logger.NewLogger(logger.WithLevel(zap.DebugLevel), logger.WithAppName("tgo"), logger.WithNamespace("nekomeowww"))
 		if err != nil {
@@ -161,7 +335,6 @@ func NewBot(callOpts ...CallOption) (*Bot, error) {
 		opts.dispatcher = dispatcher
 	}
 
-	var err error
 	var b *tgbotapi.BotAPI
 
 	if opts.apiEndpoint != "" {
@@ -173,144 +346,267 @@ func NewBot(callOpts ...CallOption) (*Bot, error) {
 		return nil, err
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+
 	bot := &Bot{
 		BotAPI:     b,
 		Dispatcher: opts.dispatcher,
 		opts:       opts,
 		logger:     opts.logger,
 		i18n:       opts.i18n,
+		ctx:        ctx,
+		cancel:     cancel,
 	}
 
 	bot.puller = channelx.NewPuller[tgbotapi.Update]().
-		WithHandler(func(update tgbotapi.Update) {
-			bot.Dispatcher.Dispatch(bot.BotAPI, bot.Bot(), bot.i18n, update)
-		}).
+		WithHandler(bot.dispatchUpdate).
 		WithPanicHandler(func(panicValues *panics.Recovered) {
 			bot.logger.Error("panic occurred", zap.Any("panic", panicValues))
 		})
 
-	// init webhook server and set webhook
+	// init webhook server, the webhook will be set when starting
 	if bot.opts.webhookURL != "" {
 		parsed, err := url.Parse(bot.opts.webhookURL)
 		if err != nil {
 			return nil, err
 		}
 
-		bot.webhookUpdateChan = make(chan tgbotapi.Update, b.Buffer)
-		bot.webhookServer = newWebhookServer(parsed.Path, bot.opts.webhookPort, bot.BotAPI, bot.webhookUpdateChan)
-		bot.puller = bot.puller.WithNotifyChannel(bot.webhookUpdateChan)
+		bot.webhookUpdates = newWebhookUpdates(b.Buffer)
+		bot.webhookHandler = newWebhookHandler(bot.opts.webhookSecretToken, bot.opts.webhookReplyTimeout, bot.Bot, bot.webhookUpdates)
+		bot.puller = bot.puller.WithNotifyChannel(bot.webhookUpdates.ch)
 
-		err = setWebhook(bot.opts.webhookURL, bot.BotAPI)
-		if err != nil {
-			return nil, err
+		if !bot.opts.withoutWebhookServer {
+			bot.webhookServer = newWebhookServer(bot.webhookPath(parsed.Path), bot.opts.webhookPort, bot.webhookHandler)
 		}
-	} else {
-		u := tgbotapi.NewUpdate(0)
-		u.Timeout = 60
-		bot.updateChan = b.GetUpdatesChan(u)
-		bot.puller = bot.puller.WithNotifyChannel(bot.updateChan)
 	}
 
-	// obtain webhook info
-	webhookInfo, err := bot.GetWebhookInfo()
+	return bot, nil
+}
+
+// WebhookHandler returns the http.Handler that receives the webhook updates, mount
+// it on Bot.WebhookPath of your own server along with WithoutWebhookServer. It
+// responds 404 when the bot is not configured with WithWebhookURL.
+func (b *Bot) WebhookHandler() http.Handler {
+	if b.webhookHandler == nil {
+		return http.NotFoundHandler()
+	}
+
+	return b.webhookHandler
+}
+
+// WebhookPath returns the path that Telegram sends the webhook requests to.
+func (b *Bot) WebhookPath() string {
+	parsed, err := url.Parse(b.opts.webhookURL)
 	if err != nil {
-		return nil, err
+		return ""
+	}
+
+	return b.webhookPath(parsed.Path)
+}
+
+// webhookPath appends the bot token to the webhook URL or path unless
+// WithWebhookWithoutTokenPath is set.
+func (b *Bot) webhookPath(urlOrPath string) string {
+	if b.opts.webhookWithoutTokenPath {
+		return lo.Ternary(urlOrPath == "", "/", urlOrPath)
 	}
-	if bot.opts.webhookURL != "" && webhookInfo.IsSet() && webhookInfo.LastErrorDate != 0 {
-		bot.logger.Error("webhook callback failed", zap.String("last_message", webhookInfo.LastErrorMessage))
+
+	return urlOrPath + "/" + b.Token
+}
+
+// subscribeUpdates sets the webhook or starts polling, asking Telegram only for the
+// update types that the registered handlers are interested in.
+func (b *Bot) subscribeUpdates() error {
+	allowedUpdates := b.AllowedUpdates()
+	b.logger.Debug("subscribing updates", zap.Strings("allowed_updates", allowedUpdates))
+
+	if b.opts.webhookURL == "" {
+		return b.initPolling(allowedUpdates)
 	}
 
-	// cancel the previous set webhook
-	if bot.opts.webhookURL == "" && webhookInfo.IsSet() {
-		_, err := bot.Request(tgbotapi.DeleteWebhookConfig{DropPendingUpdates: true})
-		if err != nil {
-			return nil, err
-		}
+	config := SetWebhookConfig{
+		URL:                b.webhookPath(b.opts.webhookURL),
+		IPAddress:          b.opts.webhookIPAddress,
+		MaxConnections:     b.opts.webhookMaxConnections,
+		AllowedUpdates:     allowedUpdates,
+		DropPendingUpdates: b.opts.dropPendingUpdates,
+		SecretToken:        b.opts.webhookSecretToken,
+	}
+	if b.opts.webhookCertificateFile != "" {
+		config.Certificate = tgbotapi.FilePath(b.opts.webhookCertificateFile)
 	}
 
-	return bot, nil
+	err := setWebhook(b.BotAPI, config)
+	if err != nil {
+		return err
+	}
+
+	// obtain webhook info
+	webhookInfo, err := b.GetWebhookInfo()
+	if err != nil {
+		return err
+	}
+	if webhookInfo.IsSet() && webhookInfo.LastErrorDate != 0 {
+		b.logger.Error("webhook callback failed", zap.String("last_message", webhookInfo.LastErrorMessage))
+	}
+
+	return nil
 }
 
+// Stop stops receiving updates, then waits for the in-flight handlers to return
+// until ctx is done. A ShutdownTimeoutError is returned when some of the handlers
+// were cut off.
 func (b *Bot) Stop(ctx context.Context) error {
-	if b.alreadyStopped {
+	if !b.alreadyStopped.CompareAndSwap(false, true) {
 		return nil
 	}
 
-	b.alreadyStopped = true
+	defer b.cancel()
 
-	if b.opts.webhookURL != "" {
+	if b.webhookServer != nil {
 		closeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
 		if err := b.webhookServer.Shutdown(closeCtx); err != nil && err != http.ErrServerClosed {
 			return fmt.Errorf("failed to shutdown webhook server: %w", err)
 		}
+	}
+	if b.webhookUpdates != nil {
+		b.webhookUpdates.close()
 
-		close(b.webhookUpdateChan)
+		// Telegram was told that the buffered updates were received, they are
+		// lost for good unless dispatched before the puller stops
+		select {
+		case <-b.webhookUpdates.drained():
+		case <-ctx.Done():
+		}
 	} else {
 		b.StopReceivingUpdates()
 	}
 
 	_ = b.puller.StopPull(ctx)
 
-	return nil
+	return b.Dispatcher.Shutdown(ctx)
+}
+
+func (b *Bot) dispatchUpdate(update tgbotapi.Update) {
+	defer b.webhookUpdates.done()
+
+	reply := b.webhookUpdates.takeReply(update.UpdateID)
+	defer reply.release()
+
+	botAPI := b.Bot()
+
+	firstSeen, err := botAPI.markUpdateAsSeen(update, b.opts.updateDeduplicationTTL)
+	if err != nil {
+		// dispatch anyway, losing an update is worse than handling it twice
+		b.logger.Error("failed to mark update as seen", zap.Int("update_id", update.UpdateID), zap.Error(err))
+	}
+	if err == nil && !firstSeen {
+		b.duplicatedUpdates.Add(1)
+		b.logger.Debug("dropped duplicated update",
+			zap.Int("update_id", update.UpdateID),
+			zap.Int64("duplicated_updates", b.duplicatedUpdates.Load()),
+		)
+
+		return
+	}
+
+	b.Dispatcher.dispatch(b.BotAPI, botAPI, b.i18n, update, reply)
+	b.persistPollingOffset(botAPI, update)
 }
 
 func (b *Bot) startPullUpdates() {
 	b.puller.StartPull(context.Background())
 }
 
+// Start subscribes the updates with the handlers registered so far, register all
+// the handlers before calling Start.
 func (b *Bot) Start(ctx context.Context) error {
-	err := fo.Invoke0(ctx, func() error {
-		if b.opts.webhookURL != "" && b.webhookServer != nil {
-			l, err := net.Listen("tcp", b.webhookServer.Addr)
-			if err != nil {
-				return err
-			}
-
-			err = b.webhookServer.Serve(l)
-			if err != nil && err != http.ErrServerClosed {
-				b.logger.Fatal("", zap.Error(err))
-			}
+	err := b.subscribeUpdates()
+	if err != nil {
+		return err
+	}
 
-			b.logger.Info("Telegram Bot webhook server is listening", zap.String("addr", b.webhookServer.Addr))
-		}
+	b.startPullUpdates()
 
+	if b.webhookServer == nil {
 		return nil
-	})
-	if err != nil {
-		return err
 	}
 
-	if b.opts.webhookURL != "" && b.webhookServer != nil {
-		b.webhookStarted = true
+	return fo.Invoke0(ctx, b.serveWebhook)
+}
+
+func (b *Bot) serveWebhook() error {
+	var err error
+
+	l := b.opts.webhookListener
+	if l == nil {
+		l, err = net.Listen("tcp", b.webhookServer.Addr)
+		if err != nil {
+			return err
+		}
 	}
 
-	b.startPullUpdates()
+	b.logger.Info("Telegram Bot webhook server is listening", zap.String("addr", l.Addr().String()))
+
+	if b.opts.webhookTLSCertFile != "" {
+		err = b.webhookServer.ServeTLS(l, b.opts.webhookTLSCertFile, b.opts.webhookTLSKeyFile)
+	} else {
+		err = b.webhookServer.Serve(l)
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
 
 	return nil
 }
 
+// Bootstrap starts the bot and stops it gracefully once SIGINT or SIGTERM is
+// received, ctx is cancelled, or the bot fails to start.
 func (b *Bot) Bootstrap(ctx context.Context) {
 	var wg sync.WaitGroup
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	wg.Add(1)
 
 	go func() {
-		_ = b.Start(ctx)
+		defer wg.Done()
 
-		wg.Done()
+		err := b.Start(ctx)
+		if err == nil {
+			return
+		}
+		if !errors.Is(err, context.Canceled) && !errors.Is(err, http.ErrServerClosed) {
+			b.logger.Error("failed to start bot", zap.Error(err))
+		}
+
+		// stop so that the handlers are drained and the webhook replies are flushed
+		cancel()
 	}()
 
 	wg.Add(1)
 
 	go func() {
+		defer wg.Done()
+
 		osCh := make(chan os.Signal, 1)
 		signal.Notify(osCh, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(osCh)
+
+		select {
+		case <-osCh:
+		case <-ctx.Done():
+		}
 
-		for range osCh {
-			wg.Done()
-			_ = b.Stop(context.Background())
+		stopCtx, cancel := context.WithTimeout(context.Background(), b.opts.shutdownTimeout)
+		defer cancel()
+
+		err := b.Stop(stopCtx)
+		if err != nil {
+			b.logger.Error("failed to stop bot gracefully", zap.Error(err))
 		}
 	}()
 
@@ -319,19 +615,27 @@ func (b *Bot) Bootstrap(ctx context.Context) {
 
 func (b *Bot) Bot() *BotAPI {
 	return &BotAPI{
-		BotAPI:   b.BotAPI,
-		logger:   b.logger,
-		queue:    b.opts.queue,
-		ttlcache: b.opts.ttlcache,
+		BotAPI:         b.BotAPI,
+		ctx:            b.ctx,
+		logger:         b.logger,
+		queue:          b.opts.queue,
+		ttlcache:       b.opts.ttlcache,
+		rateLimiter:    b.opts.rateLimiter,
+		sendRateLimits: b.opts.sendRateLimits,
+		retryPolicy:    b.opts.retryPolicy,
+
+		chatMigrationHooks: b.chatMigrationHooks,
 	}
 }
 
-func (b *Bot) MayMakeRequest(endpoint string, params tgbotapi.Params) *tgbotapi.APIResponse {
+func (b *Bot) MayMakeRequest(endpoint string, params tgbotapi.Params, callOpts ...RequestCallOption) *tgbotapi.APIResponse {
 	may := fo.NewMay[*tgbotapi.APIResponse]().Use(func(err error, messageArgs ...any) {
-		b.logger.Error("failed to send request to telegram endpoint: "+endpoint, zap.String("request", xo.SprintJSON(params)), zap.Error(err))
+		logBotAPIError(b.logger, "failed to send request to telegram endpoint: "+endpoint, err, zap.String("request", xo.SprintJSON(params)))
 	})
 
-	return may.Invoke(b.MakeRequest(endpoint, params))
+	return may.Invoke(withRetry(b.ctx, b.logger, retryPolicyOf(b.opts.retryPolicy, callOpts), func() (*tgbotapi.APIResponse, error) {
+		return b.Bot().MakeRequest(endpoint, params)
+	}))
 }
 
 func (b *Bot) PinChatMessage(config PinChatMessageConfig) error {
@@ -359,51 +663,133 @@ func (b *Bot) UnpinChatMessage(config UnpinChatMessageConfig) error {
 type BotAPI struct {
 	*tgbotapi.BotAPI
 
-	logger   *logger.Logger
-	queue    queue.Queue
-	ttlcache ttlcache.TTLCache
+	ctx            context.Context
+	logger         *logger.Logger
+	queue          queue.Queue
+	ttlcache       ttlcache.TTLCache
+	rateLimiter    ratelimit.RateLimiter
+	sendRateLimits *SendRateLimits
+	retryPolicy    RetryPolicy
+
+	chatMigrationHooks []ChatMigrationHook
+}
+
+// context returns the context of the bot, which is cancelled once the bot stopped.
+func (b *BotAPI) context() context.Context {
+	if b.ctx == nil {
+		return context.Background()
+	}
+
+	return b.ctx
 }
 
-func (b *BotAPI) MaySend(chattable tgbotapi.Chattable) *tgbotapi.Message {
+func (b *BotAPI) MaySend(chattable tgbotapi.Chattable, callOpts ...RequestCallOption) *tgbotapi.Message {
 	may := fo.NewMay[tgbotapi.Message]().Use(func(err error, messageArgs ...any) {
-		b.logger.Error("failed to send message to telegram", zap.String("message", xo.SprintJSON(chattable)), zap.Error(err))
+		logBotAPIError(b.logger, "failed to send message to telegram", err, zap.String("message", xo.SprintJSON(chattable)))
+	})
+
+	return lo.ToPtr(may.Invoke(withRetry(b.context(), b.logger, retryPolicyOf(b.retryPolicy, callOpts), func() (tgbotapi.Message, error) {
+		return b.Send(chattable)
+	})))
+}
+
+func (b *BotAPI) MaySendMediaGroup(config tgbotapi.MediaGroupConfig, callOpts ...RequestCallOption) []tgbotapi.Message {
+	may := fo.NewMay[[]tgbotapi.Message]().Use(func(err error, messageArgs ...any) {
+		logBotAPIError(b.logger, "failed to send media group to telegram", err, zap.String("message", xo.SprintJSON(config)))
 	})
 
-	return lo.ToPtr(may.Invoke(b.Send(chattable)))
+	return may.Invoke(withRetry(b.context(), b.logger, retryPolicyOf(b.retryPolicy, callOpts), func() ([]tgbotapi.Message, error) {
+		return b.SendMediaGroup(config)
+	}))
 }
 
-func (b *BotAPI) MayRequest(chattable tgbotapi.Chattable) *tgbotapi.APIResponse {
+func (b *BotAPI) MayRequest(chattable tgbotapi.Chattable, callOpts ...RequestCallOption) *tgbotapi.APIResponse {
 	may := fo.NewMay[*tgbotapi.APIResponse]().Use(func(err error, messageArgs ...any) {
-		b.logger.Error("failed to send request to telegram", zap.String("request", xo.SprintJSON(chattable)), zap.Error(err))
+		logBotAPIError(b.logger, "failed to send request to telegram", err, zap.String("request", xo.SprintJSON(chattable)))
 	})
 
-	return may.Invoke(b.Request(chattable))
+	return may.Invoke(withRetry(b.context(), b.logger, retryPolicyOf(b.retryPolicy, callOpts), func() (*tgbotapi.APIResponse, error) {
+		return b.Request(chattable)
+	}))
 }
 
-func (b *BotAPI) IsCannotInitiateChatWithUserErr(err error) bool {
-	if err == nil {
-		return false
+// Send sends the chattable like tgbotapi.BotAPI.Send does, failures of the Bot API
+// are returned as *BotAPIError. The sends are scheduled within the budgets set by
+// WithSendRateLimits, and sent again to the supergroup when the group migrated.
+func (b *BotAPI) Send(chattable tgbotapi.Chattable) (tgbotapi.Message, error) {
+	return withChatMigration(b, chattable, b.scheduledSend)
+}
+
+// SendMediaGroup sends the media group like tgbotapi.BotAPI.SendMediaGroup does,
+// scheduled and sent again to the supergroup like Send.
+func (b *BotAPI) SendMediaGroup(config tgbotapi.MediaGroupConfig) ([]tgbotapi.Message, error) {
+	return withChatMigration(b, config, func(chattable tgbotapi.Chattable) ([]tgbotapi.Message, error) {
+		return withSendBudget(b, chattable, func(chattable tgbotapi.Chattable) ([]tgbotapi.Message, error) {
+			mediaGroup, _ := chattable.(tgbotapi.MediaGroupConfig)
+			return b.BotAPI.SendMediaGroup(mediaGroup)
+		})
+	})
+}
+
+// Request sends the chattable like tgbotapi.BotAPI.Request does, failures of the
+// Bot API are returned as *BotAPIError. The requests sending messages are
+// scheduled like Send, and the requests are sent again to the supergroup when the
+// group migrated.
+func (b *BotAPI) Request(chattable tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	return withChatMigration(b, chattable, func(chattable tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+		if isSendChattable(chattable) {
+			return withSendBudget(b, chattable, b.BotAPI.Request)
+		}
+
+		resp, err := b.BotAPI.Request(chattable)
+
+		return resp, NewBotAPIError(err)
+	})
+}
+
+// MakeRequest makes the request to the endpoint like tgbotapi.BotAPI.MakeRequest
+// does, failures of the Bot API are returned as *BotAPIError. The requests are
+// scheduled and sent again to the supergroup like Request.
+func (b *BotAPI) MakeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error) {
+	resp, err := b.makeRequest(endpoint, params)
+
+	var botAPIErr *BotAPIError
+	if !errors.As(err, &botAPIErr) || botAPIErr.MigrateToChatID == 0 || params["chat_id"] == "" {
+		return resp, err
 	}
 
-	tgbotapiErr, ok := err.(*tgbotapi.Error)
-	if !ok {
-		return false
+	fromChatID, _ := strconv.ParseInt(params["chat_id"], 10, 64)
+
+	migrateErr := b.MigrateChat(fromChatID, botAPIErr.MigrateToChatID)
+	if migrateErr != nil {
+		b.logger.Error("failed to migrate chat", zap.Int64("from_chat_id", fromChatID), zap.Error(migrateErr))
 	}
 
-	return tgbotapiErr.Code == 403 && tgbotapiErr.Message == "Forbidden: bot can't initiate conversation with a user"
+	migrated := maps.Clone(params)
+	migrated["chat_id"] = strconv.FormatInt(botAPIErr.MigrateToChatID, 10)
+
+	return b.makeRequest(endpoint, migrated)
 }
 
-func (b *BotAPI) IsBotWasBlockedByTheUserErr(err error) bool {
-	if err == nil {
-		return false
+func (b *BotAPI) makeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error) {
+	if !isSendMethod(endpoint) {
+		resp, err := b.BotAPI.MakeRequest(endpoint, params)
+		return resp, NewBotAPIError(err)
 	}
 
-	tgbotapiErr, ok := err.(*tgbotapi.Error)
-	if !ok {
-		return false
-	}
+	chat, isGroup, hasChat := chatOfParams(params)
+
+	return withChatSendBudget(b, chat, isGroup, hasChat, func() (*tgbotapi.APIResponse, error) {
+		return b.BotAPI.MakeRequest(endpoint, params)
+	})
+}
+
+func (b *BotAPI) IsCannotInitiateChatWithUserErr(err error) bool {
+	return errors.Is(NewBotAPIError(err), ErrCannotInitiateChatWithUser)
+}
 
-	return tgbotapiErr.Code == 403 && tgbotapiErr.Message == "Forbidden: bot was blocked by the user"
+func (b *BotAPI) IsBotWasBlockedByTheUserErr(err error) bool {
+	return errors.Is(NewBotAPIError(err), ErrBotWasBlockedByTheUser)
 }
 
 func (b *BotAPI) IsBotAdministrator(chatID int64) (bool, error) {
@@ -505,7 +891,7 @@ func (b *BotAPI) DeleteAllDeleteLaterMessages(forUserID int64) error {
 }
 
 func (b *BotAPI) AssignOneNopCallbackQueryData() (string, error) {
-	return b.AssignOneCallbackQueryData("nop", "")
+	return b.AssignOneCallbackQueryData(nopCallbackQueryRoute, "")
 }
 
 func (b *BotAPI) AssignOneCallbackQueryData(route string, data any) (string, error) {
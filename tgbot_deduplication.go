@@ -0,0 +1,24 @@
+package tgo
+
+import (
+	"context"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nekomeowww/tgo/pkg/redis"
+)
+
+// markUpdateAsSeen records the update_id in the TTL cache, reports false when
+// the update has already been seen within ttl.
+func (b *BotAPI) markUpdateAsSeen(update tgbotapi.Update, ttl time.Duration) (bool, error) {
+	if ttl <= 0 || update.UpdateID == 0 {
+		return true, nil
+	}
+
+	return b.ttlcache.SetNX(context.Background(), redis.UpdateDeduplication2.Format(b.Self.ID, update.UpdateID), "1", ttl)
+}
+
+// DuplicatedUpdatesCount returns how many duplicated updates were dropped.
+func (b *Bot) DuplicatedUpdatesCount() int64 {
+	return b.duplicatedUpdates.Load()
+}
@@ -0,0 +1,77 @@
+package tgo
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nekomeowww/tgo/pkg/redis"
+	"go.uber.org/zap"
+)
+
+// Telegram keeps the pending updates for 24 hours, there is no point to remember the offset for longer.
+const pollingOffsetTTL = 24 * time.Hour
+
+func (b *BotAPI) loadPollingOffset() (int, error) {
+	str, err := b.ttlcache.Get(context.Background(), redis.UpdatePollingOffset1.Format(b.Self.ID))
+	if err != nil {
+		return 0, err
+	}
+	if str.IsAbsent() {
+		return 0, nil
+	}
+
+	return strconv.Atoi(str.MustGet())
+}
+
+func (b *BotAPI) savePollingOffset(offset int) error {
+	return b.ttlcache.Set(context.Background(), redis.UpdatePollingOffset1.Format(b.Self.ID), strconv.Itoa(offset), pollingOffsetTTL)
+}
+
+func (b *Bot) initPolling(allowedUpdates []string) error {
+	webhookInfo, err := b.GetWebhookInfo()
+	if err != nil {
+		return err
+	}
+
+	// cancel the previous set webhook, getUpdates won't work while a webhook is set,
+	// deleteWebhook is also the way to drop the pending updates for polling
+	if webhookInfo.IsSet() || b.opts.dropPendingUpdates {
+		_, err := b.Request(tgbotapi.DeleteWebhookConfig{DropPendingUpdates: b.opts.dropPendingUpdates})
+		if err != nil {
+			return err
+		}
+	}
+
+	var offset int
+
+	if !b.opts.dropPendingUpdates {
+		offset, err = b.Bot().loadPollingOffset()
+		if err != nil {
+			b.logger.Error("failed to load persisted polling offset, polling from the oldest pending update", zap.Error(err))
+		}
+		if offset != 0 {
+			b.logger.Info("resuming polling from persisted offset", zap.Int("offset", offset))
+		}
+	}
+
+	u := tgbotapi.NewUpdate(offset)
+	u.Timeout = 60
+	u.AllowedUpdates = allowedUpdates
+	b.updateChan = b.GetUpdatesChan(u)
+	b.puller = b.puller.WithNotifyChannel(b.updateChan)
+
+	return nil
+}
+
+func (b *Bot) persistPollingOffset(botAPI *BotAPI, update tgbotapi.Update) {
+	if b.updateChan == nil {
+		return
+	}
+
+	err := botAPI.savePollingOffset(update.UpdateID + 1)
+	if err != nil {
+		b.logger.Error("failed to persist polling offset", zap.Int("update_id", update.UpdateID), zap.Error(err))
+	}
+}
@@ -0,0 +1,173 @@
+package tgo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"github.com/nekomeowww/xo/logger"
+)
+
+// RetryPolicy decides how the failed Bot API requests are retried. Requests are
+// retried on 5xx, network errors, responses that aren't JSON and 429 Too Many
+// Requests, but never on the other 4xx such as 400 Bad Request and 403 Forbidden,
+// which fail the same way again.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one, 1 or
+	// less disables the retries.
+	MaxAttempts int
+	// InitialBackoff is the backoff before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the backoff, a retry_after of 429 longer than it isn't
+	// waited for and the request fails instead.
+	MaxBackoff time.Duration
+	// Multiplier grows the backoff after every retry, defaults to 2.
+	Multiplier float64
+	// Jitter randomizes the backoff by up to the fraction of it, 0-1.
+	Jitter float64
+}
+
+// DefaultRetryPolicy retries up to 2 times, backing off from 500ms.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     30 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// NoRetry sends the requests only once.
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// backoff returns the backoff before the retry-th retry, starting from 1.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	d := float64(p.InitialBackoff) * math.Pow(multiplier, float64(retry-1))
+	if p.MaxBackoff > 0 {
+		d = math.Min(d, float64(p.MaxBackoff))
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (rand.Float64()*2 - 1) //nolint:gosec
+	}
+
+	return time.Duration(d)
+}
+
+type requestOptions struct {
+	retryPolicy *RetryPolicy
+}
+
+type RequestCallOption func(*requestOptions)
+
+// WithRequestRetryPolicy overrides the retry policy set by WithRetryPolicy for the call.
+func WithRequestRetryPolicy(policy RetryPolicy) RequestCallOption {
+	return func(o *requestOptions) {
+		o.retryPolicy = &policy
+	}
+}
+
+// WithoutRequestRetry sends the request of the call only once.
+func WithoutRequestRetry() RequestCallOption {
+	return WithRequestRetryPolicy(NoRetry)
+}
+
+func retryPolicyOf(defaultPolicy RetryPolicy, callOpts []RequestCallOption) RetryPolicy {
+	opts := &requestOptions{}
+
+	for _, callOpt := range callOpts {
+		callOpt(opts)
+	}
+	if opts.retryPolicy != nil {
+		return *opts.retryPolicy
+	}
+
+	return defaultPolicy
+}
+
+// retryableAfter reports whether the request that failed with err is worth
+// retrying, and the minimum duration to wait before the retry.
+func retryableAfter(err error) (time.Duration, bool) {
+	if err == nil {
+		return 0, false
+	}
+
+	var tgbotapiErr *tgbotapi.Error
+	if errors.As(err, &tgbotapiErr) {
+		switch {
+		case tgbotapiErr.Code == 429:
+			return time.Duration(tgbotapiErr.RetryAfter) * time.Second, true
+		case tgbotapiErr.Code >= 500:
+			return 0, true
+		default:
+			return 0, false
+		}
+	}
+
+	// e.g. the HTML error page of a proxy in front of the Bot API, or a truncated
+	// response body
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return 0, true
+	}
+
+	var netErr net.Error
+
+	return 0, errors.As(err, &netErr)
+}
+
+// withRetry calls fn until it succeeds, fails with an error that isn't worth
+// retrying, the attempts of the policy run out, or ctx is done while backing off.
+func withRetry[T any](ctx context.Context, logger *logger.Logger, policy RetryPolicy, fn func() (T, error)) (T, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := fn()
+		if err == nil || attempt >= policy.MaxAttempts {
+			return result, err
+		}
+
+		retryAfter, ok := retryableAfter(err)
+		if !ok {
+			return result, err
+		}
+		if policy.MaxBackoff > 0 && retryAfter > policy.MaxBackoff {
+			return result, err
+		}
+
+		backoff := max(policy.backoff(attempt), retryAfter)
+
+		logger.Warn("request to telegram failed, retrying",
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff),
+			zap.Error(err),
+		)
+
+		if !sleepContext(ctx, backoff) {
+			logger.Debug("retrying request to telegram cancelled", zap.Error(ctx.Err()))
+			return result, err
+		}
+	}
+}
+
+// sleepContext sleeps for d, reports false when ctx is done before.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
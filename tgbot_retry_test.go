@@ -0,0 +1,166 @@
+package tgo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nekomeowww/xo/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRetryableAfter(t *testing.T) {
+	_, ok := retryableAfter(nil)
+	assert.False(t, ok)
+
+	_, ok = retryableAfter(&tgbotapi.Error{Code: 400, Message: "Bad Request: message text is empty"})
+	assert.False(t, ok)
+
+	_, ok = retryableAfter(&tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"})
+	assert.False(t, ok)
+
+	_, ok = retryableAfter(&tgbotapi.Error{Code: 502, Message: "Bad Gateway"})
+	assert.True(t, ok)
+
+	retryAfter, ok := retryableAfter(&tgbotapi.Error{
+		Code:               429,
+		Message:            "Too Many Requests: retry after 5",
+		ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 5},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, retryAfter)
+
+	_, ok = retryableAfter(&net.OpError{Op: "dial", Err: errors.New("connection refused")})
+	assert.True(t, ok)
+
+	_, ok = retryableAfter(&json.SyntaxError{Offset: 1})
+	assert.True(t, ok)
+
+	_, ok = retryableAfter(fmt.Errorf("decode: %w", io.ErrUnexpectedEOF))
+	assert.True(t, ok)
+
+	_, ok = retryableAfter(errors.New("unknown"))
+	assert.False(t, ok)
+}
+
+func TestWithRetry(t *testing.T) {
+	logger, err := logger.NewLogger(logger.WithLevel(zapcore.DebugLevel), logger.WithAppName("tgo"), logger.WithNamespace("nekomeowww"))
+	require.NoError(t, err)
+
+	policy := RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     10 * time.Millisecond,
+		Jitter:         0.5,
+	}
+
+	t.Run("Recovered", func(t *testing.T) {
+		attempts := 0
+
+		result, err := withRetry(context.Background(), logger, policy, func() (int, error) {
+			attempts++
+			if attempts < 3 {
+				return 0, &tgbotapi.Error{Code: 500, Message: "Internal Server Error"}
+			}
+
+			return 42, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 42, result)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("AttemptsRunOut", func(t *testing.T) {
+		attempts := 0
+
+		_, err := withRetry(context.Background(), logger, policy, func() (int, error) {
+			attempts++
+			return 0, &tgbotapi.Error{Code: 500, Message: "Internal Server Error"}
+		})
+		require.Error(t, err)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("NotRetryable", func(t *testing.T) {
+		attempts := 0
+
+		_, err := withRetry(context.Background(), logger, policy, func() (int, error) {
+			attempts++
+			return 0, &tgbotapi.Error{Code: 400, Message: "Bad Request"}
+		})
+		require.Error(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("RetryAfterTooLong", func(t *testing.T) {
+		attempts := 0
+
+		_, err := withRetry(context.Background(), logger, policy, func() (int, error) {
+			attempts++
+			return 0, &tgbotapi.Error{Code: 429, ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 60}}
+		})
+		require.Error(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("ProxyErrorPage", func(t *testing.T) {
+		attempts := 0
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			attempts++
+			if attempts == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				_, _ = w.Write([]byte("<html><body>502 Bad Gateway</body></html>"))
+
+				return
+			}
+
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": true})
+		}))
+		defer server.Close()
+
+		bot := &tgbotapi.BotAPI{Token: "token", Client: server.Client()}
+		bot.SetAPIEndpoint(server.URL + "/bot%s/%s")
+
+		_, err := withRetry(context.Background(), logger, policy, func() (*tgbotapi.APIResponse, error) {
+			return bot.Request(tgbotapi.NewDeleteMessage(1, 1))
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		attempts := 0
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := withRetry(ctx, logger, RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Minute}, func() (int, error) {
+			attempts++
+			return 0, &tgbotapi.Error{Code: 500, Message: "Internal Server Error"}
+		})
+		require.Error(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("Overridden", func(t *testing.T) {
+		attempts := 0
+
+		_, err := withRetry(context.Background(), logger, retryPolicyOf(policy, []RequestCallOption{WithoutRequestRetry()}), func() (int, error) {
+			attempts++
+			return 0, &tgbotapi.Error{Code: 500, Message: "Internal Server Error"}
+		})
+		require.Error(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+}
@@ -0,0 +1,214 @@
+package tgo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nekomeowww/tgo/pkg/redis"
+	"go.uber.org/zap"
+)
+
+// RateLimit allows Limit requests per Per.
+type RateLimit struct {
+	Limit int
+	Per   time.Duration
+}
+
+// SendRateLimits are the budgets of sending messages, a zero RateLimit disables the budget.
+//
+// https://core.telegram.org/bots/faq#my-bot-is-hitting-limits-how-do-i-avoid-this
+type SendRateLimits struct {
+	Global   RateLimit
+	PerChat  RateLimit
+	PerGroup RateLimit
+}
+
+// DefaultSendRateLimits are the limits documented by Telegram.
+var DefaultSendRateLimits = SendRateLimits{
+	Global:   RateLimit{Limit: 30, Per: time.Second},
+	PerChat:  RateLimit{Limit: 1, Per: time.Second},
+	PerGroup: RateLimit{Limit: 20, Per: time.Minute},
+}
+
+// scheduledSend sends the chattable, waits until the send rate limits set by
+// WithSendRateLimits allow, and postpones the following sends to the chat for
+// retry_after when Telegram responds with 429 Too Many Requests. MaySend retries
+// such sends with the retry policy.
+func (b *BotAPI) scheduledSend(chattable tgbotapi.Chattable) (tgbotapi.Message, error) {
+	return withSendBudget(b, chattable, b.BotAPI.Send)
+}
+
+// withSendBudget calls fn with the chattable within the send rate limits, like
+// scheduledSend does.
+func withSendBudget[T any](b *BotAPI, chattable tgbotapi.Chattable, fn func(tgbotapi.Chattable) (T, error)) (T, error) {
+	chat, isGroup, hasChat := chatOfChattable(chattable)
+
+	return withChatSendBudget(b, chat, isGroup, hasChat, func() (T, error) {
+		return fn(chattable)
+	})
+}
+
+// withChatSendBudget calls fn within the send rate limits of the chat.
+func withChatSendBudget[T any](b *BotAPI, chat string, isGroup bool, hasChat bool, fn func() (T, error)) (T, error) {
+	if b.sendRateLimits == nil || b.rateLimiter == nil {
+		result, err := fn()
+		return result, NewBotAPIError(err)
+	}
+
+	b.waitForSendBudget(chat, isGroup, hasChat)
+
+	result, err := fn()
+
+	err = NewBotAPIError(err)
+
+	retryAfter := retryAfterOf(err)
+	if retryAfter <= 0 {
+		return result, err
+	}
+
+	// the flood limit of the chat when sending to a chat, the reserving of the
+	// keys honours the blocks even when their budgets are disabled
+	keys := []string{redis.SendRateLimitGlobal1.Format(b.Self.ID)}
+	if hasChat {
+		keys = []string{redis.SendRateLimitChat2.Format(b.Self.ID, chat)}
+	}
+	if hasChat && isGroup {
+		keys = append(keys, redis.SendRateLimitGroup2.Format(b.Self.ID, chat))
+	}
+
+	for _, key := range keys {
+		b.logger.Warn("hit flood limit of telegram, postponing the sends",
+			zap.String("key", key),
+			zap.Duration("retry_after", retryAfter),
+		)
+
+		blockErr := b.rateLimiter.Block(context.Background(), key, retryAfter)
+		if blockErr != nil {
+			b.logger.Error("failed to postpone the sends", zap.String("key", key), zap.Error(blockErr))
+		}
+	}
+
+	return result, err
+}
+
+func (b *BotAPI) waitForSendBudget(chat string, isGroup bool, hasChat bool) {
+	var wait time.Duration
+
+	// a disabled budget is still reserved, so that the sends wait for its blocks
+	reserve := func(key string, limit RateLimit) {
+		d, err := b.rateLimiter.Reserve(context.Background(), key, limit.Limit, limit.Per)
+		if err != nil {
+			b.logger.Error("failed to reserve send rate limit budget, sending without waiting", zap.String("key", key), zap.Error(err))
+			return
+		}
+
+		wait = max(wait, d)
+	}
+
+	reserve(redis.SendRateLimitGlobal1.Format(b.Self.ID), b.sendRateLimits.Global)
+
+	if hasChat {
+		reserve(redis.SendRateLimitChat2.Format(b.Self.ID, chat), b.sendRateLimits.PerChat)
+	}
+	if hasChat && isGroup {
+		reserve(redis.SendRateLimitGroup2.Format(b.Self.ID, chat), b.sendRateLimits.PerGroup)
+	}
+	if wait <= 0 {
+		return
+	}
+
+	b.logger.Debug("waiting for send rate limit budget", zap.String("chat", chat), zap.Duration("wait", wait))
+
+	if !sleepContext(b.context(), wait) {
+		b.logger.Debug("waiting for send rate limit budget cancelled, sending without waiting", zap.String("chat", chat))
+	}
+}
+
+// isSendMethod reports whether the Bot API method sends messages, which are
+// subject to the flood limits.
+func isSendMethod(method string) bool {
+	switch method {
+	case "sendChatAction":
+		return false
+	case "forwardMessage", "forwardMessages", "copyMessage", "copyMessages":
+		return true
+	default:
+		return strings.HasPrefix(method, "send")
+	}
+}
+
+// chatOfChattable returns the chat id or channel username that the chattable is
+// sent to, and whether the chat is a group or channel, which have negative ids.
+func chatOfChattable(chattable tgbotapi.Chattable) (string, bool, bool) {
+	v := reflect.Indirect(reflect.ValueOf(chattable))
+	if v.Kind() != reflect.Struct {
+		return "", false, false
+	}
+
+	chatID, ok := chatIDOfChattable(chattable)
+	if ok {
+		return strconv.FormatInt(chatID, 10), chatID < 0, true
+	}
+
+	channelUsername := v.FieldByName("ChannelUsername")
+	if channelUsername.IsValid() && channelUsername.Kind() == reflect.String && channelUsername.String() != "" {
+		return channelUsername.String(), true, true
+	}
+
+	return "", false, false
+}
+
+// isSendChattable reports whether the chattable sends messages like isSendMethod.
+func isSendChattable(chattable tgbotapi.Chattable) bool {
+	switch chattable.(type) {
+	case tgbotapi.MessageConfig,
+		tgbotapi.ForwardConfig,
+		tgbotapi.CopyMessageConfig,
+		tgbotapi.PhotoConfig,
+		tgbotapi.AudioConfig,
+		tgbotapi.DocumentConfig,
+		tgbotapi.StickerConfig,
+		tgbotapi.VideoConfig,
+		tgbotapi.AnimationConfig,
+		tgbotapi.VideoNoteConfig,
+		tgbotapi.VoiceConfig,
+		tgbotapi.MediaGroupConfig,
+		tgbotapi.LocationConfig,
+		tgbotapi.VenueConfig,
+		tgbotapi.ContactConfig,
+		tgbotapi.SendPollConfig,
+		tgbotapi.DiceConfig,
+		tgbotapi.GameConfig,
+		tgbotapi.InvoiceConfig:
+		return true
+	default:
+		return false
+	}
+}
+
+// chatOfParams returns the chat of the request params like chatOfChattable.
+func chatOfParams(params tgbotapi.Params) (string, bool, bool) {
+	chat := params["chat_id"]
+	if chat == "" {
+		return "", false, false
+	}
+
+	return chat, strings.HasPrefix(chat, "@") || strings.HasPrefix(chat, "-"), true
+}
+
+func retryAfterOf(err error) time.Duration {
+	var tgbotapiErr *tgbotapi.Error
+	if !errors.As(err, &tgbotapiErr) {
+		return 0
+	}
+	if tgbotapiErr.Code != 429 || tgbotapiErr.RetryAfter <= 0 {
+		return 0
+	}
+
+	return time.Duration(tgbotapiErr.RetryAfter) * time.Second
+}
@@ -0,0 +1,97 @@
+package tgo
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nekomeowww/tgo/pkg/redis"
+	"github.com/nekomeowww/tgo/pkg/storage/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatOfChattable(t *testing.T) {
+	chat, isGroup, ok := chatOfChattable(tgbotapi.NewMessage(123, "hello"))
+	assert.True(t, ok)
+	assert.False(t, isGroup)
+	assert.Equal(t, "123", chat)
+
+	chat, isGroup, ok = chatOfChattable(tgbotapi.NewPhoto(-100123, tgbotapi.FileID("file")))
+	assert.True(t, ok)
+	assert.True(t, isGroup)
+	assert.Equal(t, "-100123", chat)
+
+	chat, isGroup, ok = chatOfChattable(tgbotapi.NewMessageToChannel("@channel", "hello"))
+	assert.True(t, ok)
+	assert.True(t, isGroup)
+	assert.Equal(t, "@channel", chat)
+
+	_, _, ok = chatOfChattable(tgbotapi.NewEditMessageText(0, 0, ""))
+	assert.False(t, ok)
+}
+
+func TestRetryAfterOf(t *testing.T) {
+	assert.Zero(t, retryAfterOf(nil))
+	assert.Zero(t, retryAfterOf(&tgbotapi.Error{Code: 400, Message: "Bad Request"}))
+	assert.Equal(t, 3*time.Second, retryAfterOf(&tgbotapi.Error{
+		Code:               429,
+		Message:            "Too Many Requests: retry after 3",
+		ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 3},
+	}))
+}
+
+func TestIsSendChattable(t *testing.T) {
+	assert.True(t, isSendChattable(tgbotapi.NewMessage(1, "hello")))
+	assert.True(t, isSendChattable(tgbotapi.NewCopyMessage(1, 2, 3)))
+	assert.False(t, isSendChattable(tgbotapi.NewChatAction(1, tgbotapi.ChatTyping)))
+	assert.False(t, isSendChattable(tgbotapi.NewDeleteMessage(1, 2)))
+
+	assert.True(t, isSendMethod("sendMessage"))
+	assert.True(t, isSendMethod("copyMessage"))
+	assert.False(t, isSendMethod("sendChatAction"))
+	assert.False(t, isSendMethod("setMessageReaction"))
+}
+
+func TestChatOfParams(t *testing.T) {
+	chat, isGroup, ok := chatOfParams(tgbotapi.Params{"chat_id": "-100123"})
+	assert.True(t, ok)
+	assert.True(t, isGroup)
+	assert.Equal(t, "-100123", chat)
+
+	_, _, ok = chatOfParams(tgbotapi.Params{})
+	assert.False(t, ok)
+}
+
+func TestRequestBlockedByFloodLimit(t *testing.T) {
+	ctx, _ := newTestContext(t, func(string, *http.Request) (any, *tgbotapi.APIResponse) {
+		return nil, &tgbotapi.APIResponse{
+			Ok:          false,
+			ErrorCode:   429,
+			Description: "Too Many Requests: retry after 3",
+			Parameters:  &tgbotapi.ResponseParameters{RetryAfter: 3},
+		}
+	})
+
+	// the budget of the chat is disabled, the block still applies
+	ctx.Bot.rateLimiter = ratelimit.NewInMemoryRateLimiter()
+	ctx.Bot.sendRateLimits = &SendRateLimits{Global: RateLimit{Limit: 30, Per: time.Second}}
+
+	_, err := ctx.Bot.Request(tgbotapi.NewMessage(-100123, "hello"))
+	require.Error(t, err)
+
+	for _, key := range []string{
+		redis.SendRateLimitChat2.Format(ctx.Bot.Self.ID, "-100123"),
+		redis.SendRateLimitGroup2.Format(ctx.Bot.Self.ID, "-100123"),
+	} {
+		wait, err := ctx.Bot.rateLimiter.Reserve(context.Background(), key, 0, 0)
+		require.NoError(t, err)
+		assert.InDelta(t, 3*time.Second, wait, float64(100*time.Millisecond))
+	}
+
+	wait, err := ctx.Bot.rateLimiter.Reserve(context.Background(), redis.SendRateLimitGlobal1.Format(ctx.Bot.Self.ID), 0, 0)
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+}
@@ -1,9 +1,16 @@
 package tgo
 
 import (
+	"context"
 	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/nekomeowww/tgo/pkg/storage/queue"
 	"github.com/nekomeowww/tgo/pkg/storage/ttlcache"
 	"github.com/nekomeowww/xo/logger"
@@ -71,3 +78,159 @@ func TestAssignOneCallbackQueryData(t *testing.T) {
 		assert.Equal(t, string(lo.Must(json.Marshal(data))), dataStr)
 	})
 }
+
+func TestMarkUpdateAsSeen(t *testing.T) {
+	logger, err := logger.NewLogger(logger.WithLevel(zapcore.DebugLevel), logger.WithAppName("tgo"), logger.WithNamespace("nekomeowww"))
+	require.NoError(t, err)
+
+	test := func(t *testing.T, bot BotAPI) {
+		update := tgbotapi.Update{UpdateID: int(time.Now().UnixNano() % 1000000000)}
+
+		firstSeen, err := bot.markUpdateAsSeen(update, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, firstSeen)
+
+		firstSeen, err = bot.markUpdateAsSeen(update, time.Minute)
+		require.NoError(t, err)
+		assert.False(t, firstSeen)
+
+		firstSeen, err = bot.markUpdateAsSeen(update, 0)
+		require.NoError(t, err)
+		assert.True(t, firstSeen)
+	}
+
+	t.Run("InMemory", func(t *testing.T) {
+		test(t, BotAPI{
+			BotAPI:   &tgbotapi.BotAPI{Self: tgbotapi.User{ID: 1}},
+			logger:   logger,
+			queue:    queue.NewInMemoryQueue(),
+			ttlcache: ttlcache.NewInMemoryTTLCache(),
+		})
+	})
+
+	t.Run("Rueidis", func(t *testing.T) {
+		c, err := rueidis.NewClient(rueidis.ClientOption{
+			InitAddress:  []string{"localhost:6379"},
+			DisableCache: true,
+		})
+		require.NoError(t, err)
+
+		test(t, BotAPI{
+			BotAPI:   &tgbotapi.BotAPI{Self: tgbotapi.User{ID: 1}},
+			logger:   logger,
+			queue:    queue.NewRueidisQueue(c),
+			ttlcache: ttlcache.NewRueidisTTLCache(c),
+		})
+	})
+}
+
+func TestPollingOffset(t *testing.T) {
+	logger, err := logger.NewLogger(logger.WithLevel(zapcore.DebugLevel), logger.WithAppName("tgo"), logger.WithNamespace("nekomeowww"))
+	require.NoError(t, err)
+
+	bot := BotAPI{
+		BotAPI:   &tgbotapi.BotAPI{Self: tgbotapi.User{ID: 1}},
+		logger:   logger,
+		queue:    queue.NewInMemoryQueue(),
+		ttlcache: ttlcache.NewInMemoryTTLCache(),
+	}
+
+	offset, err := bot.loadPollingOffset()
+	require.NoError(t, err)
+	assert.Zero(t, offset)
+
+	require.NoError(t, bot.savePollingOffset(42))
+
+	offset, err = bot.loadPollingOffset()
+	require.NoError(t, err)
+	assert.Equal(t, 42, offset)
+}
+
+func TestBootstrapCancelled(t *testing.T) {
+	logger, err := logger.NewLogger(logger.WithLevel(zapcore.DebugLevel), logger.WithAppName("tgo"), logger.WithNamespace("nekomeowww"))
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := any(true)
+		if r.URL.Path == "/bottoken/getMe" {
+			result = map[string]any{"id": 1, "is_bot": true, "first_name": "tgo", "username": "tgo_bot"}
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+	}))
+	defer server.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	bot, err := NewBot(
+		WithToken("token"),
+		WithAPIEndpoint(server.URL),
+		WithLogger(logger),
+		WithWebhookURL("https://example.com/webhook"),
+		WithWebhookListener(listener),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	bootstrapped := make(chan struct{})
+
+	go func() {
+		defer close(bootstrapped)
+
+		bot.Bootstrap(ctx)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-bootstrapped:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "Bootstrap didn't return after ctx was cancelled")
+	}
+
+	assert.True(t, bot.alreadyStopped.Load())
+}
+
+func TestStopDispatchesBufferedWebhookUpdates(t *testing.T) {
+	logger, err := logger.NewLogger(logger.WithLevel(zapcore.DebugLevel), logger.WithAppName("tgo"), logger.WithNamespace("nekomeowww"))
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := any(true)
+		if r.URL.Path == "/bottoken/getMe" {
+			result = map[string]any{"id": 1, "is_bot": true, "first_name": "tgo", "username": "tgo_bot"}
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+	}))
+	defer server.Close()
+
+	bot, err := NewBot(
+		WithToken("token"),
+		WithAPIEndpoint(server.URL),
+		WithLogger(logger),
+		WithWebhookURL("https://example.com/webhook"),
+		WithoutWebhookServer(),
+	)
+	require.NoError(t, err)
+
+	var dispatched atomic.Int64
+
+	bot.Use(func(ctx *Context, next func()) {
+		dispatched.Add(1)
+	})
+
+	for i := 1; i <= 50; i++ {
+		require.True(t, bot.webhookUpdates.push(tgbotapi.Update{UpdateID: i}))
+	}
+
+	bot.startPullUpdates()
+	require.NoError(t, bot.Stop(context.Background()))
+
+	assert.Equal(t, int64(50), dispatched.Load())
+	assert.True(t, bot.alreadyStopped.Load())
+	require.NoError(t, bot.Stop(context.Background()))
+}
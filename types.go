@@ -19,3 +19,25 @@ const (
 	ChatTypeSuperGroup ChatType = "supergroup"
 	ChatTypeChannel    ChatType = "channel"
 )
+
+type MessageEntityType string
+
+const (
+	MessageEntityTypeMention       MessageEntityType = "mention"
+	MessageEntityTypeHashtag       MessageEntityType = "hashtag"
+	MessageEntityTypeCashtag       MessageEntityType = "cashtag"
+	MessageEntityTypeBotCommand    MessageEntityType = "bot_command"
+	MessageEntityTypeURL           MessageEntityType = "url"
+	MessageEntityTypeEmail         MessageEntityType = "email"
+	MessageEntityTypePhoneNumber   MessageEntityType = "phone_number"
+	MessageEntityTypeBold          MessageEntityType = "bold"
+	MessageEntityTypeItalic        MessageEntityType = "italic"
+	MessageEntityTypeUnderline     MessageEntityType = "underline"
+	MessageEntityTypeStrikethrough MessageEntityType = "strikethrough"
+	MessageEntityTypeSpoiler       MessageEntityType = "spoiler"
+	MessageEntityTypeBlockquote    MessageEntityType = "blockquote"
+	MessageEntityTypeCode          MessageEntityType = "code"
+	MessageEntityTypePre           MessageEntityType = "pre"
+	MessageEntityTypeTextLink      MessageEntityType = "text_link"
+	MessageEntityTypeTextMention   MessageEntityType = "text_mention"
+)
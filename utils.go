@@ -6,6 +6,7 @@ import (
 	"strings"
 	"unicode/utf8"
 
+	"github.com/nekomeowww/tgo/pkg/tghtml"
 	"github.com/nekomeowww/xo"
 )
 
@@ -76,15 +77,11 @@ func FullNameFromFirstAndLastName(firstName, lastName string) string {
 
 // EscapeHTMLSymbols
 //
+//	& with &amp;
 //	< with &lt;
 //	> with &gt;
-//	& with &amp;
 func EscapeHTMLSymbols(str string) string {
-	str = strings.ReplaceAll(str, "<", "&lt;")
-	str = strings.ReplaceAll(str, ">", "&gt;")
-	str = strings.ReplaceAll(str, "&", "&amp;")
-
-	return str
+	return tghtml.Escape(str)
 }
 
 var regexpHTMLBlocks = regexp.MustCompile(`<[^>]*>`)
@@ -40,3 +40,7 @@ func TestReplaceMarkdownTitlesToBoldTexts(t *testing.T) {
 		a.Equal(expect, actual)
 	})
 }
+
+func TestEscapeHTMLSymbols(t *testing.T) {
+	assert.Equal(t, "&lt;b&gt;Tom &amp; Jerry&lt;/b&gt;", EscapeHTMLSymbols("<b>Tom & Jerry</b>"))
+}
@@ -1,20 +1,133 @@
 package tgo
 
 import (
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"regexp"
+	"sync"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/samber/lo"
 )
 
-func newWebhookServer(patternPath, port string, bot *tgbotapi.BotAPI, updateChan chan<- tgbotapi.Update) *http.Server {
-	srv := http.NewServeMux()
-	srv.HandleFunc(patternPath+"/"+bot.Token, func(w http.ResponseWriter, r *http.Request) {
-		update, err := bot.HandleUpdate(r)
+const webhookSecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+var webhookSecretTokenRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+func validateWebhookSecretToken(secretToken string) error {
+	if secretToken == "" {
+		return nil
+	}
+	if !webhookSecretTokenRegexp.MatchString(secretToken) {
+		return errors.New("webhook secret token must be 1-256 characters of A-Z, a-z, 0-9, _ and -")
+	}
+
+	return nil
+}
+
+func verifyWebhookSecretToken(r *http.Request, secretToken string) bool {
+	if secretToken == "" {
+		return true
+	}
+
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get(webhookSecretTokenHeader)), []byte(secretToken)) == 1
+}
+
+// webhookUpdates is the channel that the webhook handler pushes updates into, the
+// handler may be mounted on a server that outlives the bot, so pushing after close
+// must be refused instead of panicking.
+type webhookUpdates struct {
+	mutex   sync.RWMutex
+	closed  bool
+	ch      chan tgbotapi.Update
+	replies sync.Map
+	// pending counts the updates pushed but not dispatched yet
+	pending sync.WaitGroup
+}
+
+func newWebhookUpdates(buffer int) *webhookUpdates {
+	return &webhookUpdates{
+		ch: make(chan tgbotapi.Update, buffer),
+	}
+}
+
+func (u *webhookUpdates) push(update tgbotapi.Update) bool {
+	u.mutex.RLock()
+	defer u.mutex.RUnlock()
+
+	if u.closed {
+		return false
+	}
+
+	u.pending.Add(1)
+	u.ch <- update
+
+	return true
+}
+
+// done marks one of the pushed updates as dispatched.
+func (u *webhookUpdates) done() {
+	if u == nil {
+		return
+	}
+
+	u.pending.Done()
+}
+
+// drained returns the channel that is closed once all the pushed updates are
+// dispatched, call it after close so that no more updates are pushed.
+func (u *webhookUpdates) drained() <-chan struct{} {
+	ch := make(chan struct{})
+
+	go func() {
+		defer close(ch)
+
+		u.pending.Wait()
+	}()
+
+	return ch
+}
+
+func (u *webhookUpdates) takeReply(updateID int) *webhookReply {
+	if u == nil {
+		return nil
+	}
+
+	reply, ok := u.replies.LoadAndDelete(updateID)
+	if !ok {
+		return nil
+	}
+
+	r, _ := reply.(*webhookReply)
+
+	return r
+}
+
+func (u *webhookUpdates) close() {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	if u.closed {
+		return
+	}
+
+	u.closed = true
+	close(u.ch)
+}
+
+func newWebhookHandler(secretToken string, replyTimeout time.Duration, bot func() *BotAPI, updates *webhookUpdates) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !verifyWebhookSecretToken(r, secretToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		update, err := bot().HandleUpdate(r)
 		if err != nil {
 			errMsg, _ := json.Marshal(map[string]string{"error": err.Error()})
 
@@ -25,8 +138,48 @@ func newWebhookServer(patternPath, port string, bot *tgbotapi.BotAPI, updateChan
 			return
 		}
 
-		updateChan <- *update
+		var reply *webhookReply
+
+		if replyTimeout > 0 {
+			replyToStore := newWebhookReply()
+
+			// the same update may be delivered again while the previous one is still handled
+			_, loaded := updates.replies.LoadOrStore(update.UpdateID, replyToStore)
+			if !loaded {
+				reply = replyToStore
+			}
+		}
+
+		// let Telegram retry later rather than losing the update
+		if !updates.push(*update) {
+			if reply != nil {
+				updates.replies.Delete(update.UpdateID)
+			}
+
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+		if reply == nil {
+			return
+		}
+
+		chattable := reply.wait(replyTimeout)
+		if chattable == nil {
+			return
+		}
+
+		err = tgbotapi.WriteToHTTPResponse(w, chattable)
+		if err != nil {
+			// e.g. files to upload, which can't be carried by the response
+			bot().MayRequest(chattable)
+		}
 	})
+}
+
+func newWebhookServer(patternPath, port string, handler http.Handler) *http.Server {
+	srv := http.NewServeMux()
+	srv.Handle(patternPath, handler)
 
 	return &http.Server{
 		Addr:              net.JoinHostPort("", lo.Ternary(port == "", "7071", port)),
@@ -36,13 +189,18 @@ func newWebhookServer(patternPath, port string, bot *tgbotapi.BotAPI, updateChan
 	}
 }
 
-func setWebhook(webhookURL string, bot *tgbotapi.BotAPI) error {
-	webhookConfig, err := tgbotapi.NewWebhook(webhookURL + "/" + bot.Token)
+func setWebhook(bot *tgbotapi.BotAPI, config SetWebhookConfig) error {
+	params, err := config.params()
 	if err != nil {
 		return fmt.Errorf("failed to create webhook config: %w", err)
 	}
 
-	_, err = bot.Request(webhookConfig)
+	files := config.files()
+	if len(files) > 0 {
+		_, err = bot.UploadFiles(config.method(), params, files)
+	} else {
+		_, err = bot.MakeRequest(config.method(), params)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to set webhook: %w", err)
 	}
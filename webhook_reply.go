@@ -0,0 +1,168 @@
+package tgo
+
+import (
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// webhookReply carries at most one Bot API method call from the handlers of an
+// update to the response body of the webhook request that delivered the update.
+//
+// The webhook request waits until every handler dispatched for the update returned
+// or the timeout is reached. Only the first action is carried, once another action
+// comes in, the carried one is handed back to be sent through the Bot API first so
+// that the actions are executed in order.
+type webhookReply struct {
+	mutex    sync.Mutex
+	pending  int
+	settled  bool
+	reply    tgbotapi.Chattable
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+func newWebhookReply() *webhookReply {
+	return &webhookReply{
+		pending: 1, // held by the dispatching of the update itself
+		done:    make(chan struct{}),
+	}
+}
+
+func (r *webhookReply) hold() {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.pending++
+}
+
+func (r *webhookReply) release() {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.pending--
+	if r.pending <= 0 {
+		r.doneOnce.Do(func() { close(r.done) })
+	}
+}
+
+// offer hands the chattable over to the webhook response, a nil chattable stands
+// for an action that can't be carried. It reports whether the chattable was
+// accepted, and returns the previously accepted one that must be sent first when
+// the reply falls back to the Bot API.
+func (r *webhookReply) offer(chattable tgbotapi.Chattable) (bool, tgbotapi.Chattable) {
+	if r == nil {
+		return false, nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.settled {
+		return false, nil
+	}
+	if chattable != nil && r.reply == nil {
+		r.reply = chattable
+		return true, nil
+	}
+
+	previous := r.reply
+	r.reply = nil
+	r.settled = true
+
+	return false, previous
+}
+
+// wait blocks until all the handlers returned or the timeout is reached, then
+// returns the chattable to be written into the webhook response, if any.
+func (r *webhookReply) wait(timeout time.Duration) tgbotapi.Chattable {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-r.done:
+	case <-timer.C:
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.settled = true
+
+	return r.reply
+}
+
+// webhookReplyChattable returns the single Bot API method call that the response
+// consists of, nil if the response can't be carried by the webhook response.
+func webhookReplyChattable(resp Response) tgbotapi.Chattable {
+	switch v := resp.(type) {
+	case MessageResponse:
+		// the sent message is needed for delete later and the callback
+		if v.deleteLaterForUserID != 0 && v.deleteLaterChatID != 0 || v.onSent != nil {
+			return nil
+		}
+
+		configs := v.messageConfigs()
+		if len(configs) != 1 {
+			return nil
+		}
+
+		return configs[0]
+	case MediaResponse:
+		if v.deleteLaterForUserID != 0 && v.deleteLaterChatID != 0 || v.onSent != nil {
+			return nil
+		}
+		// the file to upload can't be carried by the response
+		if v.file() != nil && v.file().NeedsUpload() {
+			return nil
+		}
+
+		chattable, captionOverflow := v.chattable()
+		if captionOverflow != nil {
+			return nil
+		}
+
+		return chattable
+	case MediaGroupResponse:
+		if v.deleteLaterForUserID != 0 && v.deleteLaterChatID != 0 || v.onSent != nil {
+			return nil
+		}
+		if needsUpload, _ := v.needsUpload(); needsUpload {
+			return nil
+		}
+
+		config, err := v.MediaGroupConfig()
+		if err != nil {
+			return nil
+		}
+
+		return config
+	case EditMessageResponse:
+		chattables := v.chattables()
+		if len(chattables) != 1 {
+			return nil
+		}
+
+		return chattables[0]
+	case ActionResponse:
+		// the endpoints that tgbotapi lacks configs for can't be written
+		if v.onSent != nil {
+			return nil
+		}
+
+		return v.chattable
+	case tgbotapi.Chattable:
+		return v
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,157 @@
+package tgo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookHandlerSecretToken(t *testing.T) {
+	updates := newWebhookUpdates(1)
+	updateChan := updates.ch
+	srv := newWebhookServer("/webhook", "", newWebhookHandler("secret_token-1", 0, func() *BotAPI { return &BotAPI{BotAPI: &tgbotapi.BotAPI{}} }, updates))
+
+	t.Run("Missing", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{"update_id":1}`))
+		rec := httptest.NewRecorder()
+
+		srv.Handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Empty(t, updateChan)
+	})
+
+	t.Run("Mismatched", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{"update_id":1}`))
+		req.Header.Set(webhookSecretTokenHeader, "wrong")
+
+		rec := httptest.NewRecorder()
+
+		srv.Handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Empty(t, updateChan)
+	})
+
+	t.Run("Matched", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{"update_id":1}`))
+		req.Header.Set(webhookSecretTokenHeader, "secret_token-1")
+
+		rec := httptest.NewRecorder()
+
+		srv.Handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.Len(t, updateChan, 1)
+		assert.Equal(t, 1, (<-updateChan).UpdateID)
+	})
+}
+
+func TestWebhookHandlerAfterClose(t *testing.T) {
+	updates := newWebhookUpdates(1)
+	handler := newWebhookHandler("", 0, func() *BotAPI { return &BotAPI{BotAPI: &tgbotapi.BotAPI{}} }, updates)
+
+	updates.close()
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"update_id":1}`))
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestWebhookHandlerReply(t *testing.T) {
+	updates := newWebhookUpdates(1)
+	handler := newWebhookHandler("", time.Second, func() *BotAPI { return &BotAPI{BotAPI: &tgbotapi.BotAPI{}} }, updates)
+
+	go func() {
+		update := <-updates.ch
+
+		reply := updates.takeReply(update.UpdateID)
+		defer reply.release()
+
+		reply.hold()
+		go func() {
+			defer reply.release()
+
+			accepted, previous := reply.offer(tgbotapi.NewMessage(1, "pong"))
+			assert.True(t, accepted)
+			assert.Nil(t, previous)
+		}()
+	}()
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"update_id":1}`))
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	values, err := url.ParseQuery(rec.Body.String())
+	require.NoError(t, err)
+	assert.Equal(t, "sendMessage", values.Get("method"))
+	assert.Equal(t, "1", values.Get("chat_id"))
+	assert.Equal(t, "pong", values.Get("text"))
+}
+
+func TestWebhookReply(t *testing.T) {
+	t.Run("NoReply", func(t *testing.T) {
+		reply := newWebhookReply()
+		reply.release()
+
+		assert.Nil(t, reply.wait(time.Second))
+	})
+
+	t.Run("MoreThanOneAction", func(t *testing.T) {
+		reply := newWebhookReply()
+
+		first := tgbotapi.NewMessage(1, "first")
+
+		accepted, previous := reply.offer(first)
+		assert.True(t, accepted)
+		assert.Nil(t, previous)
+
+		accepted, previous = reply.offer(tgbotapi.NewMessage(1, "second"))
+		assert.False(t, accepted)
+		assert.Equal(t, first, previous)
+
+		reply.release()
+		assert.Nil(t, reply.wait(time.Second))
+	})
+
+	t.Run("Slow", func(t *testing.T) {
+		reply := newWebhookReply()
+		assert.Nil(t, reply.wait(10*time.Millisecond))
+
+		accepted, previous := reply.offer(tgbotapi.NewMessage(1, "late"))
+		assert.False(t, accepted)
+		assert.Nil(t, previous)
+	})
+
+	t.Run("DeleteLater", func(t *testing.T) {
+		assert.NotNil(t, webhookReplyChattable(NewMessage(1, "message")))
+		assert.Nil(t, webhookReplyChattable(NewMessage(1, "message").WithDeleteLater(1, 1)))
+		assert.Nil(t, webhookReplyChattable(NewMessage(1, "message").OnSent(func(*Context, *tgbotapi.Message) {})))
+	})
+
+	t.Run("Chattable", func(t *testing.T) {
+		assert.Equal(t, tgbotapi.NewDeleteMessage(1, 1), webhookReplyChattable(tgbotapi.NewDeleteMessage(1, 1)))
+	})
+
+	t.Run("Media", func(t *testing.T) {
+		assert.NotNil(t, webhookReplyChattable(NewPhoto(1, FileFromID("file_id"))))
+		assert.NotNil(t, webhookReplyChattable(NewLocation(1, 1, 1)))
+		assert.Nil(t, webhookReplyChattable(NewPhoto(1, FileFromPath("photo.jpg"))))
+		assert.Nil(t, webhookReplyChattable(NewPhoto(1, FileFromURL("https://example.com/photo.jpg")).WithCaption(strings.Repeat("a", CaptionLengthLimit+1))))
+	})
+}
+
+func TestValidateWebhookSecretToken(t *testing.T) {
+	require.NoError(t, validateWebhookSecretToken(""))
+	require.NoError(t, validateWebhookSecretToken("abc_DEF-123"))
+	require.Error(t, validateWebhookSecretToken("abc def"))
+	require.Error(t, validateWebhookSecretToken(strings.Repeat("a", 257)))
+}